@@ -4,5 +4,6 @@ go 1.14
 
 require (
 	github.com/stretchr/testify v1.7.0
+	golang.org/x/net v0.0.0-20210226172049-e18ecbb05110
 	golang.org/x/text v0.3.5
 )
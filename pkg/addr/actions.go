@@ -78,19 +78,19 @@ func ApplyActions(m *rd.Match, mk interface{}) error {
 		return err
 	}
 
-	return passThroughMadeObject(m.Made, mk)
+	return passThroughMadeObject(m, mk)
 }
 
-func passThroughMadeObject(m, mk interface{}) error {
+func passThroughMadeObject(m *rd.Match, mk interface{}) error {
 	if mk == nil {
 		return nil
 	}
 
-	if m == nil {
+	if m.Made == nil {
 		return ErrParseConstruction
 	}
 
-	switch mv := m.(type) {
+	switch mv := m.Made.(type) {
 	case *Mailbox:
 		switch mkv := mk.(type) {
 		case *Address:
@@ -133,15 +133,9 @@ func passThroughMadeObject(m, mk interface{}) error {
 	case *AddrSpec:
 		switch mkv := mk.(type) {
 		case *Address:
-			*mkv = &Mailbox{
-				address:  mv,
-				original: mv.original,
-			}
+			*mkv, _ = madeMailbox(m)
 		case **Mailbox:
-			*mkv = &Mailbox{
-				address:  mv,
-				original: mv.original,
-			}
+			*mkv, _ = madeMailbox(m)
 		case **AddrSpec:
 			*mkv = mv
 		default:
@@ -189,6 +183,36 @@ func applyGroupActions(m *rd.Match) {
 	}
 }
 
+// madeMailbox returns the mailbox made for the given match. A bare addr-spec
+// is promoted to a mailbox, keeping any comments found within it.
+func madeMailbox(m *rd.Match) (*Mailbox, bool) {
+	switch mv := m.Made.(type) {
+	case *Mailbox:
+		return mv, true
+	case *AddrSpec:
+		return &Mailbox{
+			address:  mv,
+			original: mv.original,
+		}, true
+	default:
+		return nil, false
+	}
+}
+
+// madeAddress returns the address made for the given match. A bare addr-spec
+// is promoted to a mailbox as is done by madeMailbox.
+func madeAddress(m *rd.Match) (Address, bool) {
+	if g, ok := m.Made.(*Group); ok {
+		return g, true
+	}
+
+	if mb, ok := madeMailbox(m); ok {
+		return mb, true
+	}
+
+	return nil, false
+}
+
 func decodeMIMEWords(in string) string {
 	dec := &mime.WordDecoder{
 		CharsetReader: CharsetReader,
@@ -214,15 +238,20 @@ func applyThisAction(m *rd.Match) (err error) {
 			dn = ""
 		}
 
-		c := accumulateComments(m)
-		m.Made, err = NewMailboxParsed(
-			dn,
-			m.Group["angle-addr"].Made.(*AddrSpec),
-			decodeMIMEWords(c),
-			strings.TrimSpace(string(m.Content)),
-		)
-		if err != nil {
-			return
+		// The comment is not checked for balanced parentheses here as it may
+		// legitimately contain escaped parentheses.
+		var cs []Comment
+		if gdn := m.Group["display-name"]; gdn != nil {
+			cs = regionComments(gdn,
+				CommentBeforeDisplayName, CommentInDisplayName, CommentAfterDisplayName)
+		}
+		cs = append(cs, angleAddrComments(m.Group["angle-addr"])...)
+
+		m.Made = &Mailbox{
+			displayName: dn,
+			address:     m.Group["angle-addr"].Made.(*AddrSpec),
+			original:    strings.TrimSpace(string(m.Content)),
+			comments:    cs,
 		}
 	case p.TAngleAddr, p.TObsAngleAddr:
 		m.Made = m.Group["addr-spec"].Made
@@ -242,24 +271,37 @@ func applyThisAction(m *rd.Match) (err error) {
 			mbl = MailboxList{}
 		}
 
-		m.Made = NewGroupParsed(
+		g := NewGroupParsed(
 			m.Group["display-name"].Made.(string),
 			mbl,
 			strings.TrimSpace(string(m.Content)),
 		)
+
+		g.comments = regionComments(m.Group["display-name"],
+			CommentBeforeDisplayName, CommentInDisplayName, CommentAfterDisplayName)
+		g.comments = groupListComments(m.Group["group-list"], g.comments)
+		g.comments = append(g.comments, regionComments(m.Group["post"],
+			CommentAfterGroup, CommentAfterGroup, CommentAfterGroup)...)
+
+		m.Made = g
 	case p.TDisplayName:
-		m.Made = decodeMIMEWords(strings.TrimSpace(m.Group["phrase"].Made.(string)))
+		gp := m.Group["phrase"]
+		if gp.Tag == p.TWords {
+			m.Made = decodeMIMEWords(gp.Made.(string))
+		} else {
+			m.Made = decodeMIMEWords(strings.TrimSpace(gp.Made.(string)))
+		}
 	case p.TMailboxList:
 		mailboxes := make(MailboxList, len(m.Submatch))
 		for i, mb := range m.Submatch {
-			mailboxes[i] = mb.Made.(*Mailbox)
+			mailboxes[i], _ = madeMailbox(mb)
 		}
 		m.Made = mailboxes
 	case p.TObsMboxList:
 		gh := m.Group["head"]
 		gt := m.Group["tail"]
 		mailboxes := make(MailboxList, 1, 1+len(gt.Made.(MailboxList)))
-		mailboxes[0] = gh.Made.(*Mailbox)
+		mailboxes[0], _ = madeMailbox(gh)
 		mailboxes = append(mailboxes, gt.Made.(MailboxList)...)
 		m.Made = mailboxes
 	case p.TObsMboxTailList:
@@ -273,36 +315,36 @@ func applyThisAction(m *rd.Match) (err error) {
 	case p.TObsMboxOptionalList:
 		gmb := m.Group["mb"]
 		if gmb != nil {
-			if mb, ok := gmb.Made.(*Mailbox); ok {
+			if mb, ok := madeMailbox(gmb); ok {
 				m.Made = mb
 			}
 		}
 	case p.TAddressList:
 		addresses := make(AddressList, len(m.Submatch))
 		for i, a := range m.Submatch {
-			addresses[i] = a.Made.(Address)
+			addresses[i], _ = madeAddress(a)
 		}
 		m.Made = addresses
 	case p.TObsAddrList:
 		gh := m.Group["head"]
 		gt := m.Group["tail"]
-		mailboxes := make(AddressList, 1+len(gt.Made.(AddressList)))
-		mailboxes[0] = gh.Made.(Address)
-		mailboxes = append(mailboxes, gt.Made.(AddressList)...)
-		m.Made = mailboxes
+		addresses := make(AddressList, 1, 1+len(gt.Made.(AddressList)))
+		addresses[0], _ = madeAddress(gh)
+		addresses = append(addresses, gt.Made.(AddressList)...)
+		m.Made = addresses
 	case p.TObsAddrTailList:
-		mailboxes := make(AddressList, 0, len(m.Submatch))
-		for _, mbo := range m.Submatch {
-			if mb, ok := mbo.Made.(Address); ok {
-				mailboxes = append(mailboxes, mb)
+		addresses := make(AddressList, 0, len(m.Submatch))
+		for _, ao := range m.Submatch {
+			if a, ok := ao.Made.(Address); ok {
+				addresses = append(addresses, a)
 			}
 		}
-		m.Made = mailboxes
+		m.Made = addresses
 	case p.TObsAddrOptionalList:
-		gmb := m.Group["mb"]
-		if gmb != nil {
-			if mb, ok := gmb.Made.(Address); ok {
-				m.Made = mb
+		ga := m.Group["address"]
+		if ga != nil {
+			if a, ok := madeAddress(ga); ok {
+				m.Made = a
 			}
 		}
 	case p.TObsLocalPart:
@@ -317,11 +359,18 @@ func applyThisAction(m *rd.Match) (err error) {
 		}
 		m.Made = a.String()
 	case p.TAddrSpec:
-		m.Made = NewAddrSpecParsed(
+		as := NewAddrSpecParsed(
 			m.Group["local-part"].Made.(string),
-			m.Group["domain"].Made.(string),
+			madeDomain(m.Group["domain"]),
 			strings.TrimSpace(string(m.Content)),
 		)
+
+		as.comments = regionComments(m.Group["local-part"],
+			CommentBeforeLocalPart, CommentInLocalPart, CommentAfterLocalPart)
+		as.comments = append(as.comments, regionComments(m.Group["domain"],
+			CommentBeforeDomain, CommentInDomain, CommentAfterDomain)...)
+
+		m.Made = as
 	case p.TObsDomain:
 		var a strings.Builder
 		a.WriteString(strings.TrimSpace(m.Group["head"].Made.(string)))
@@ -332,7 +381,10 @@ func applyThisAction(m *rd.Match) (err error) {
 		m.Made = a.String()
 	case p.TWords:
 		var a strings.Builder
-		for _, w := range m.Submatch {
+		for i, w := range m.Submatch {
+			if i > 0 && (endsWithCFWS(m.Submatch[i-1].Content) || startsWithCFWS(w.Content)) {
+				a.WriteRune(' ')
+			}
 			a.WriteString(w.Made.(string))
 		}
 		m.Made = a.String()
@@ -357,32 +409,48 @@ func applyThisAction(m *rd.Match) (err error) {
 // 	return x
 // }
 
-var (
-	quotable = map[byte]struct{}{}
-)
-
-func init() {
-	qps := []byte{
-		' ', '\t', 0x0, 0x1, 0x8,
-		0xb, 0xc, 0x7f, '\n', '\r',
-	}
-	for _, qp := range qps {
-		quotable[qp] = struct{}{}
-	}
-	for qp := byte(0xe); qp <= 0x1f; qp++ {
-		quotable[qp] = struct{}{}
+// madeDomain returns the domain made for the given match. For a domain literal,
+// this is the literal within and including the brackets without any
+// surrounding comments or whitespace.
+func madeDomain(m *rd.Match) string {
+	if gl := m.Group["literal"]; gl != nil {
+		c := m.Content
+		if gp := m.Group["pre-literal"]; gp != nil {
+			c = c[len(gp.Content):]
+		}
+		if gp := m.Group["post-literal"]; gp != nil {
+			c = c[:len(c)-len(gp.Content)]
+		}
+		return string(c)
 	}
+
+	return m.Made.(string)
 }
 
+// startsWithCFWS returns true if the matched content begins with whitespace or
+// a comment.
+func startsWithCFWS(x []byte) bool {
+	return len(x) > 0 && (x[0] == ' ' || x[0] == '\t' || x[0] == '\r' || x[0] == '\n' || x[0] == '(')
+}
+
+// endsWithCFWS returns true if the matched content ends with whitespace or a
+// comment.
+func endsWithCFWS(x []byte) bool {
+	l := len(x) - 1
+	return l >= 0 && (x[l] == ' ' || x[l] == '\t' || x[l] == '\r' || x[l] == '\n' || x[l] == ')')
+}
+
+// unquotePairs replaces each quoted-pair with the character quoted, as RFC
+// 5322 section 3.2.1 defines it: a backslash followed by any character stands
+// for that character alone. This includes the obs-qp form, which quotes NUL,
+// CR, LF, and other control characters. Those are decoded as well, so parsed
+// text may contain them; CheckSafe reports them before rendering.
 func unquotePairs(x []byte) []byte {
 	output := make([]byte, 0, len(x))
 	escaping := false
 	for _, c := range x {
 		if escaping {
 			escaping = false
-			if _, ok := quotable[c]; !ok {
-				output = append(output, '\\')
-			}
 			output = append(output, c)
 		} else if c == '\\' {
 			escaping = true
@@ -393,26 +461,3 @@ func unquotePairs(x []byte) []byte {
 
 	return output
 }
-
-func accumulateComments(m *rd.Match) string {
-	c, _ := accumulateCommentsInner(m)
-	return c
-}
-
-func accumulateCommentsInner(m *rd.Match) (string, bool) {
-	switch m.Tag {
-	case p.TCContents:
-		return string(m.Content), true
-	default:
-		cs := make([]string, 0)
-		for _, sm := range m.Submatch {
-			if c, ok := accumulateCommentsInner(sm); ok {
-				cs = append(cs, c)
-			}
-		}
-		if len(cs) > 0 {
-			return strings.Join(cs, " "), true
-		}
-		return "", false
-	}
-}
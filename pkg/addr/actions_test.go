@@ -41,8 +41,7 @@ func TestApplyActionsTNameAddrHappy(t *testing.T) {
 
 	assert.Equal(t, &Mailbox{
 		displayName: "Zip",
-		address:     &AddrSpec{"zip", "example.com", "zip@example.com"},
-		comment:     "",
+		address:     &AddrSpec{localPart: "zip", domain: "example.com", original: "zip@example.com"},
 		original:    email,
 	}, mb)
 }
@@ -97,7 +96,62 @@ func TestApplyActionsTAddrSpecHappy(t *testing.T) {
 	assert.NoError(t, err)
 
 	assert.Equal(t,
-		&AddrSpec{"moomoo", "example.com", "moomoo@example.com"},
+		&AddrSpec{localPart: "moomoo", domain: "example.com", original: "moomoo@example.com"},
 		a,
 	)
 }
+
+func TestUnquotePairs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in, out string
+	}{
+		{`plain`, `plain`},
+		{`john\"doe`, `john"doe`},
+		{`back\\slash`, `back\slash`},
+		{`a\ b`, `a b`},
+		{`\x\y`, `xy`},
+		{"obs\\\r\\\nqp", "obs\r\nqp"},
+		{`trailing\`, `trailing`},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.out, string(unquotePairs([]byte(tc.in))), tc.in)
+	}
+}
+
+func TestParseQuotedPairs(t *testing.T) {
+	t.Parallel()
+
+	as, err := ParseEmailAddrSpec(`"john\"doe\\"@example.com`)
+	if assert.NoError(t, err) {
+		assert.Equal(t, `john"doe\`, as.LocalPart())
+		assert.Equal(t, `"john\"doe\\"@example.com`, as.CleanString())
+	}
+
+	mb, err := ParseEmailMailbox(`"Smith\, John" <john@example.com> (note \(1\))`)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Smith, John", mb.DisplayName())
+		assert.Equal(t, "note (1)", mb.Comment())
+	}
+}
+
+func TestParseDomainLiteral(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in, domain string
+	}{
+		{"john@[192.0.2.1]", "[192.0.2.1]"},
+		{"john@[192.0.2.1 ]", "[192.0.2.1 ]"},
+		{"john@ (c) [192.0.2.1] (d)", "[192.0.2.1]"},
+	}
+
+	for _, tc := range tests {
+		as, err := ParseEmailAddrSpec(tc.in)
+		if assert.NoError(t, err, tc.in) {
+			assert.Equal(t, tc.domain, as.Domain(), tc.in)
+		}
+	}
+}
@@ -22,12 +22,44 @@
 // (either AddressList or MailboxList) are not quite able to totally preserve
 // the original as these are just slices. Any extra comments or whitespace
 // not associated with a mailbox or group email address in the originally parsed
-// text will be lost by those data structures.
+// text will be lost by those data structures. If you need to keep those as
+// well, use ParseEmailAddressListPreserving to parse into a ParsedAddressList,
+// which keeps the complete original text of the list.
+//
+// Clean Strings
+//
+// The CleanString() methods of Mailbox, Group, and AddrSpec all share the same
+// rendering rules, which are provided by the format package. Display names and
+// comments are quoted, escaped, or encoded as MIME words as required, and local
+// parts are quoted when they are not a valid dot-atom. As a result, parsing the
+// output of CleanString() produces a value equal to the original (ignoring the
+// original string). The one exception is an address whose local part or domain
+// contains characters that cannot be represented in RFC 5322 at all (e.g.,
+// non-ASCII characters or a domain that is neither a dot-atom nor a domain
+// literal).
+//
+// CleanString() never outputs a character for which format.IsUnsafeRune
+// returns true, such as CR, LF, NUL, or a bidi override. Any such character
+// found in a display name, comment, local part, or domain is dropped, so a
+// value containing one does not survive the round trip. Use CheckSafe to
+// detect these values or FoldedHeader to have them rejected with an error.
+//
+// Comments
+//
+// Every comment found by the parser is kept. The Comment() methods return the
+// text of all the comments joined together, but Mailbox, Group, and AddrSpec
+// also provide a Comments() method returning each comment separately with its
+// raw and decoded text and its position within the address (see
+// CommentPosition). The comments found within an addr-spec belong to the
+// AddrSpec and the comments found within the mailboxes of a group belong to
+// those mailboxes. Comments are not included in CleanString() output except
+// for the comment of a Mailbox.
 package addr
 
 import (
 	"strings"
 
+	"github.com/zostay/go-addr/pkg/format"
 	"github.com/zostay/go-addr/pkg/rfc5322"
 )
 
@@ -67,16 +99,7 @@ func (as AddressList) OriginalString() string {
 // CleanString returns the canonical version of the addresses in the list joined
 // together with a comma and a space.
 func (as AddressList) CleanString() string {
-	var a strings.Builder
-	first := true
-	for _, addr := range as {
-		if !first {
-			a.WriteString(", ")
-		}
-		a.WriteString(addr.CleanString())
-		first = false
-	}
-	return a.String()
+	return Formatter{}.FormatAddressList(as)
 }
 
 // String is an alias for CleanString.
@@ -84,6 +107,29 @@ func (as AddressList) String() string {
 	return as.CleanString()
 }
 
+// FoldedHeader renders the addresses as a complete header field with the
+// given name, e.g., "To". Each address is rendered using CleanString and the
+// result is folded to fit the line length limits of RFC 5322. Folds are placed
+// between addresses when possible and within an address only when it is too
+// long to fit on a line alone. The lines are separated by CRLF, but no CRLF is
+// added after the final line.
+//
+// This returns an *UnsafeTextError if any display name, comment, or address
+// contains a control character or bidi override (see CheckSafe). It returns
+// format.ErrLineTooLong if some part of an address cannot be folded to fit
+// within the 998 octet line limit.
+func (as AddressList) FoldedHeader(field string) (string, error) {
+	if err := as.CheckSafe(); err != nil {
+		return "", err
+	}
+
+	items := make([]string, len(as))
+	for i, addr := range as {
+		items[i] = addr.CleanString()
+	}
+	return format.FoldList(field, items)
+}
+
 // Flatten returns the AddressList as a MailboxList. This returns a slice of
 // Mailboxes. If the AddressList contains any groups, then the returned
 // MailboxList will contain all the mailboxes within those groups.
@@ -1,6 +1,8 @@
 package addr
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -30,3 +32,29 @@ func TestFlatten(t *testing.T) {
 
 	assert.Equal(t, "email@example.com", ml[0].Address())
 }
+
+func TestFoldedHeader(t *testing.T) {
+	t.Parallel()
+
+	as := make([]string, 300)
+	for i := range as {
+		as[i] = fmt.Sprintf("\"Recipient %d\" <recipient%d@example.com>", i, i)
+	}
+
+	al, err := ParseEmailAddressList(strings.Join(as, ", "))
+	assert.NoError(t, err)
+
+	h, err := al.FoldedHeader("To")
+	assert.NoError(t, err)
+
+	for _, l := range strings.Split(h, "\r\n") {
+		assert.LessOrEqual(t, len(l), 78)
+	}
+
+	unfolded := strings.ReplaceAll(h, "\r\n", "")
+	assert.Equal(t, "To: "+al.CleanString(), unfolded)
+
+	al2, err := ParseEmailAddressList(strings.TrimPrefix(h, "To:"))
+	assert.NoError(t, err)
+	assert.Len(t, al2, 300)
+}
@@ -1,7 +1,6 @@
 package addr
 
 import (
-	"fmt"
 	"strings"
 
 	"github.com/zostay/go-addr/pkg/format"
@@ -16,6 +15,7 @@ type AddrSpec struct {
 	localPart string
 	domain    string
 	original  string
+	comments  []Comment
 }
 
 // DisplayName always returns an empty string.
@@ -24,27 +24,35 @@ func (as *AddrSpec) DisplayName() string { return "" }
 // Address is an alias for CleanString.
 func (as *AddrSpec) Address() string { return as.String() }
 
-// Comment always returns an empty string.
-func (as *AddrSpec) Comment() string { return "" }
+// Comment returns the text of every comment found within the address joined
+// with spaces or an empty string if there are none. See Comments for the
+// individual comments.
+func (as *AddrSpec) Comment() string { return joinComments(as.comments) }
 
 // LocalPart returns the part of the email address from before the at sign.
 func (as *AddrSpec) LocalPart() string { return as.localPart }
 
 // SetLocalPart sets the part of the email address before the at sign. This will
-// also clear the original string if set.
+// also clear the original string if set and drop any comments found within the
+// local part.
 func (as *AddrSpec) SetLocalPart(lp string) {
 	as.localPart = lp
 	as.original = ""
+	as.comments = dropComments(as.comments,
+		CommentBeforeLocalPart, CommentInLocalPart, CommentAfterLocalPart)
 }
 
 // Domain returns the part of the email address after the at sign.
 func (as *AddrSpec) Domain() string { return as.domain }
 
 // SetDomain sets the part of the email address after the at sign. This will
-// also clear the original string if set.
+// also clear the original string if set and drop any comments found within the
+// domain.
 func (as *AddrSpec) SetDomain(d string) {
 	as.domain = d
 	as.original = ""
+	as.comments = dropComments(as.comments,
+		CommentBeforeDomain, CommentInDomain, CommentAfterDomain)
 }
 
 // OriginalString returns the originally parsed string if that string is set.
@@ -56,15 +64,20 @@ func (as *AddrSpec) OriginalString() string {
 }
 
 // CleanString will return a clean version of the email address suitable for use
-// in new email messages.
+// in new email messages. The local part is quoted if it is not a valid dot-atom
+// (see format.RenderLocalPart).
+//
+// Unsafe characters (see format.IsUnsafeRune) are dropped from the local part
+// and domain, so the output may name a different address than this one. Use
+// CheckSafe to find out if that is the case.
 func (as *AddrSpec) CleanString() string {
-	return fmt.Sprintf("%s@%s",
-		format.MaybeEscape(as.LocalPart(), false),
-		as.Domain(),
+	return format.RenderAddrSpec(
+		format.StripUnsafe(as.localPart),
+		format.StripUnsafe(as.domain),
 	)
 }
 
-// String is an alias for CleanString.
+// String is an alias for OriginalString.
 func (as *AddrSpec) String() string { return as.OriginalString() }
 
 // NewAddrSpec creates a new AddrSpec object from the given local part and
@@ -0,0 +1,447 @@
+package addr
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/textproto"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/zostay/go-addr/pkg/format"
+)
+
+// These errors are reported in a CalloutResult when an address cannot be
+// verified because it cannot be sent to the mail exchanger.
+var (
+	// ErrUnsafeCommand is reported when the hostname or the address contains
+	// a character that cannot be sent in an SMTP command, such as CR, LF, or
+	// NUL. Sending it could inject additional commands into the session.
+	ErrUnsafeCommand = errors.New("unsafe character in SMTP command")
+
+	// ErrSMTPUTF8Required is reported when the address contains characters
+	// outside of US-ASCII, but the mail exchanger does not support the
+	// SMTPUTF8 extension of RFC 6531.
+	ErrSMTPUTF8Required = errors.New("address requires SMTPUTF8")
+)
+
+// Dialer is the interface used to connect to mail exchangers. It is satisfied
+// by *net.Dialer. Tests may provide an implementation that connects to a fake
+// SMTP server instead.
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// CalloutStatus classifies the reply of a mail exchanger to a recipient.
+type CalloutStatus int
+
+// These are the callout statuses.
+const (
+	// CalloutUnknown means no reply was received, e.g., because no mail
+	// exchanger could be reached.
+	CalloutUnknown CalloutStatus = iota
+
+	// CalloutAccepted means the recipient was accepted.
+	CalloutAccepted
+
+	// CalloutRejected means the recipient, or the transaction, was rejected
+	// with a permanent error.
+	CalloutRejected
+
+	// CalloutTempFail means the recipient, or the transaction, was rejected
+	// with a temporary error.
+	CalloutTempFail
+
+	// CalloutCatchAll means the recipient was accepted, but so was a recipient
+	// that almost certainly does not exist, so the server accepts mail for
+	// every recipient of the domain.
+	CalloutCatchAll
+
+	// CalloutNoMailHost means the domain cannot receive mail, so no mail
+	// exchanger was contacted. See CheckDeliverability.
+	CalloutNoMailHost
+)
+
+var calloutStatusNames = []string{
+	"unknown",
+	"accepted",
+	"rejected",
+	"temporary failure",
+	"catch-all",
+	"no mail host",
+}
+
+// String returns a short description of the status.
+func (s CalloutStatus) String() string {
+	if s < 0 || int(s) >= len(calloutStatusNames) {
+		return "unknown"
+	}
+
+	return calloutStatusNames[s]
+}
+
+// CalloutResult reports the outcome of a callout for a single address.
+type CalloutResult struct {
+	AddrSpec *AddrSpec     // the address verified
+	Status   CalloutStatus // the classification of the reply
+	Host     string        // the mail exchanger that replied, if any
+	Code     int           // the SMTP reply code, if any
+	Message  string        // the SMTP reply text, if any
+	Err      error         // the error that prevented a reply, if any
+}
+
+// These are the defaults used by NewCalloutVerifier.
+const (
+	DefaultCalloutPort        = "25"
+	DefaultCalloutTimeout     = 30 * time.Second
+	DefaultCalloutConcurrency = 4
+)
+
+// CalloutVerifier verifies addresses by connecting to the mail exchanger of
+// each domain and asking whether it will accept mail for the address. It sends
+// EHLO (or HELO), "MAIL FROM:<>", and "RCPT TO" with the address as an RFC 5321
+// path, then classifies the reply. No message is ever sent. It is safe for
+// concurrent use.
+//
+// Many servers accept every recipient during the transaction or refuse to
+// answer callouts at all, and some treat them as abuse, so the results are
+// only a hint. Use the rate limit and concurrency settings to be a good
+// neighbor.
+type CalloutVerifier struct {
+	// Dialer is used to connect to mail exchangers.
+	Dialer Dialer
+
+	// Checker is used to find the mail exchangers for a domain.
+	Checker *DeliverabilityChecker
+
+	// Hostname is the name sent with EHLO. It should be a name that resolves
+	// to the address the verifier connects from.
+	Hostname string
+
+	// Port is the port to connect to.
+	Port string
+
+	// Timeout limits the time spent on each connection.
+	Timeout time.Duration
+
+	// Concurrency is the number of connections VerifyList makes at once.
+	Concurrency int
+
+	// Interval is the minimum time between the start of one connection and
+	// the next. Zero means connections are not rate limited.
+	Interval time.Duration
+
+	// DetectCatchAll, when set, sends a second RCPT TO with a random local part
+	// after a recipient is accepted in order to detect catch-all servers.
+	DetectCatchAll bool
+
+	lock sync.Mutex
+	next time.Time
+
+	probeLocalPart func() string
+}
+
+// NewCalloutVerifier returns a verifier that connects using the dialer, finds
+// mail exchangers using the resolver, and identifies itself with the hostname.
+// It detects catch-all servers and uses the default port, timeout, and
+// concurrency with no rate limit.
+func NewCalloutVerifier(d Dialer, r Resolver, hostname string) *CalloutVerifier {
+	return &CalloutVerifier{
+		Dialer:         d,
+		Checker:        NewDeliverabilityChecker(r),
+		Hostname:       hostname,
+		Port:           DefaultCalloutPort,
+		Timeout:        DefaultCalloutTimeout,
+		Concurrency:    DefaultCalloutConcurrency,
+		DetectCatchAll: true,
+		probeLocalPart: randomLocalPart,
+	}
+}
+
+// randomLocalPart returns a local part that is very unlikely to exist.
+func randomLocalPart() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return "no-such-user-" + hex.EncodeToString(b)
+}
+
+// wait blocks until the rate limit permits another connection.
+func (v *CalloutVerifier) wait(ctx context.Context) error {
+	v.lock.Lock()
+	now := time.Now()
+	start := v.next
+	if start.Before(now) {
+		start = now
+	}
+	v.next = start.Add(v.Interval)
+	v.lock.Unlock()
+
+	if d := start.Sub(now); d > 0 {
+		t := time.NewTimer(d)
+		defer t.Stop()
+
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// mailHosts returns the hosts to try, in order of preference.
+func (v *CalloutVerifier) mailHosts(ctx context.Context, as *AddrSpec) ([]string, CalloutStatus, error) {
+	d := as.Domain()
+	if strings.HasPrefix(d, "[") {
+		lit := strings.TrimSuffix(strings.TrimPrefix(d, "["), "]")
+		if len(lit) > 5 && strings.EqualFold(lit[:5], "IPv6:") {
+			lit = lit[5:]
+		}
+		return []string{lit}, CalloutUnknown, nil
+	}
+
+	r, err := v.Checker.Check(ctx, as)
+	if err != nil {
+		return nil, CalloutUnknown, err
+	}
+
+	switch r.Status {
+	case DeliverableMX:
+		mxs := make([]*net.MX, len(r.MX))
+		copy(mxs, r.MX)
+		sort.SliceStable(mxs, func(i, j int) bool { return mxs[i].Pref < mxs[j].Pref })
+
+		hosts := make([]string, len(mxs))
+		for i, mx := range mxs {
+			hosts[i] = strings.TrimSuffix(mx.Host, ".")
+		}
+		return hosts, CalloutUnknown, nil
+	case DeliverableImplicitMX:
+		return []string{r.Domain}, CalloutUnknown, nil
+	default:
+		return nil, CalloutNoMailHost, nil
+	}
+}
+
+// classify returns the status for an SMTP reply code.
+func classify(code int) CalloutStatus {
+	switch code / 100 {
+	case 2:
+		return CalloutAccepted
+	case 4:
+		return CalloutTempFail
+	case 5:
+		return CalloutRejected
+	default:
+		return CalloutUnknown
+	}
+}
+
+// checkCommandText returns ErrUnsafeCommand if the text contains an ASCII
+// control character or any other rune for which format.IsUnsafeRune returns
+// true. Unless unicode is set, it returns ErrSMTPUTF8Required if the text
+// contains any other character outside of US-ASCII.
+func checkCommandText(s string, unicode bool) error {
+	for _, c := range s {
+		switch {
+		case c < ' ' || c == utf8.RuneError || format.IsUnsafeRune(c):
+			return ErrUnsafeCommand
+		case c > '~' && !unicode:
+			return ErrSMTPUTF8Required
+		}
+	}
+
+	return nil
+}
+
+// hasExtension returns true if the EHLO reply text announces the extension.
+func hasExtension(ehlo, ext string) bool {
+	lines := strings.Split(ehlo, "\n")
+	for _, l := range lines[1:] {
+		if fs := strings.Fields(l); len(fs) > 0 && strings.EqualFold(fs[0], ext) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cmd sends a command and reads the reply.
+func cmd(c *textproto.Conn, format string, args ...interface{}) (int, string, error) {
+	id, err := c.Cmd(format, args...)
+	if err != nil {
+		return 0, "", err
+	}
+
+	c.StartResponse(id)
+	defer c.EndResponse(id)
+
+	return c.ReadResponse(0)
+}
+
+// converse runs the SMTP transaction with a single host.
+func (v *CalloutVerifier) converse(ctx context.Context, host string, as *AddrSpec) (*CalloutResult, error) {
+	if err := v.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	if v.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, v.Timeout)
+		defer cancel()
+	}
+
+	conn, err := v.Dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, v.Port))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if dl, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(dl)
+	}
+
+	c := textproto.NewConn(conn)
+	res := &CalloutResult{AddrSpec: as, Host: host}
+	reply := func(code int, msg string) *CalloutResult {
+		res.Status, res.Code, res.Message = classify(code), code, msg
+		return res
+	}
+
+	code, msg, err := c.ReadResponse(0)
+	if err != nil {
+		return nil, err
+	} else if code/100 != 2 {
+		return reply(code, msg), nil
+	}
+
+	ehlo := true
+	code, msg, err = cmd(c, "EHLO %s", v.Hostname)
+	if err == nil && code/100 == 5 {
+		ehlo = false
+		code, msg, err = cmd(c, "HELO %s", v.Hostname)
+	}
+	if err != nil {
+		return nil, err
+	} else if code/100 != 2 {
+		return reply(code, msg), nil
+	}
+
+	mailFrom := "MAIL FROM:<>"
+	if checkCommandText(as.path(), false) != nil {
+		if !ehlo || !hasExtension(msg, "SMTPUTF8") {
+			_, _, _ = cmd(c, "QUIT")
+			res.Err = ErrSMTPUTF8Required
+			return res, nil
+		}
+		mailFrom += " SMTPUTF8"
+	}
+
+	code, msg, err = cmd(c, mailFrom)
+	if err != nil {
+		return nil, err
+	} else if code/100 != 2 {
+		return reply(code, msg), nil
+	}
+
+	code, msg, err = cmd(c, "RCPT TO:%s", as.path())
+	if err != nil {
+		return nil, err
+	}
+	reply(code, msg)
+
+	if res.Status == CalloutAccepted && v.DetectCatchAll {
+		probeLocalPart := v.probeLocalPart
+		if probeLocalPart == nil {
+			probeLocalPart = randomLocalPart
+		}
+
+		probe := NewAddrSpec(probeLocalPart(), as.domain)
+		if pcode, _, err := cmd(c, "RCPT TO:%s", probe.path()); err == nil && pcode/100 == 2 {
+			res.Status = CalloutCatchAll
+		}
+	}
+
+	_, _, _ = cmd(c, "QUIT")
+	return res, nil
+}
+
+// Verify runs a callout for the address. Each mail exchanger of the domain is
+// tried in order of preference until one replies. If none can be reached, the
+// status is CalloutUnknown and the error of the last attempt is reported in
+// the result.
+//
+// No connection is made if the hostname or the address contains a character
+// that cannot be sent in an SMTP command. The status is CalloutUnknown and
+// ErrUnsafeCommand is reported in the result instead. An address containing
+// other characters outside of US-ASCII is only sent to a mail exchanger that
+// supports SMTPUTF8. Otherwise, ErrSMTPUTF8Required is reported.
+func (v *CalloutVerifier) Verify(ctx context.Context, as *AddrSpec) *CalloutResult {
+	if err := checkCommandText(v.Hostname, false); err != nil {
+		return &CalloutResult{AddrSpec: as, Status: CalloutUnknown, Err: ErrUnsafeCommand}
+	}
+
+	if err := checkCommandText(as.path(), true); err != nil {
+		return &CalloutResult{AddrSpec: as, Status: CalloutUnknown, Err: err}
+	}
+
+	hosts, status, err := v.mailHosts(ctx, as)
+	if err != nil || len(hosts) == 0 {
+		return &CalloutResult{AddrSpec: as, Status: status, Err: err}
+	}
+
+	for _, host := range hosts {
+		var res *CalloutResult
+		res, err = v.converse(ctx, host, as)
+		if err == nil {
+			return res
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return &CalloutResult{AddrSpec: as, Status: CalloutUnknown, Err: err}
+}
+
+// VerifyList runs a callout for every mailbox of the list, including the
+// mailboxes within groups. Up to Concurrency callouts are run at once. The
+// results are returned in the order of the mailboxes in the list.
+func (v *CalloutVerifier) VerifyList(ctx context.Context, al AddressList) []*CalloutResult {
+	var ass []*AddrSpec
+	for _, mb := range al.Flatten() {
+		if mb != nil {
+			ass = append(ass, mb.AddrSpec())
+		}
+	}
+
+	n := v.Concurrency
+	if n < 1 {
+		n = 1
+	}
+
+	results := make([]*CalloutResult, len(ass))
+	sem := make(chan struct{}, n)
+
+	var wg sync.WaitGroup
+	for i, as := range ass {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, as *AddrSpec) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			results[i] = v.Verify(ctx, as)
+		}(i, as)
+	}
+
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,278 @@
+package addr
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSMTP is a Dialer that answers every connection with a fake SMTP server.
+type fakeSMTP struct {
+	lock     sync.Mutex
+	hosts    map[string]bool // hosts that accept connections
+	catchAll map[string]bool // domains that accept every recipient
+	rcpt     map[string]int  // reply codes by path, 550 when missing
+	noEHLO   bool            // reject EHLO so that HELO is used
+	smtputf8 bool            // announce the SMTPUTF8 extension
+	commands []string
+}
+
+func (s *fakeSMTP) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, _, _ := net.SplitHostPort(address)
+	if !s.hosts[host] {
+		return nil, errors.New("connection refused")
+	}
+
+	client, server := net.Pipe()
+	go s.serve(server)
+	return client, nil
+}
+
+func (s *fakeSMTP) serve(conn net.Conn) {
+	c := textproto.NewConn(conn)
+	defer c.Close()
+
+	_ = c.PrintfLine("220 mx.example.com ESMTP")
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return
+		}
+
+		s.lock.Lock()
+		s.commands = append(s.commands, line)
+		s.lock.Unlock()
+
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch {
+		case verb == "EHLO" && s.noEHLO:
+			_ = c.PrintfLine("502 not implemented")
+		case verb == "EHLO":
+			_ = c.PrintfLine("250-mx.example.com")
+			if s.smtputf8 {
+				_ = c.PrintfLine("250-SMTPUTF8")
+			}
+			_ = c.PrintfLine("250 SIZE 1000000")
+		case verb == "HELO", verb == "MAIL":
+			_ = c.PrintfLine("250 ok")
+		case verb == "RCPT":
+			path := strings.TrimPrefix(line, "RCPT TO:")
+			code, ok := s.rcpt[path]
+			if !ok {
+				code = 550
+				if i := strings.LastIndex(path, "@"); i > -1 && s.catchAll[strings.TrimSuffix(path[i+1:], ">")] {
+					code = 250
+				}
+			}
+			_ = c.PrintfLine("%d reply", code)
+		case verb == "QUIT":
+			_ = c.PrintfLine("221 bye")
+			return
+		default:
+			_ = c.PrintfLine("500 what?")
+		}
+	}
+}
+
+func newCalloutTest() (*CalloutVerifier, *fakeSMTP) {
+	fr := newFakeResolver()
+	fr.mx["example.com"] = []*net.MX{
+		{Host: "backup.example.com.", Pref: 20},
+		{Host: "down.example.com.", Pref: 5},
+		{Host: "mx.example.com.", Pref: 10},
+	}
+	fr.mx["catchall.example.net"] = []*net.MX{{Host: "mx.catchall.example.net.", Pref: 10}}
+
+	s := &fakeSMTP{
+		hosts: map[string]bool{
+			"mx.example.com":          true,
+			"mx.catchall.example.net": true,
+			"implicit.example.org":    true,
+			"192.0.2.1":               true,
+		},
+		catchAll: map[string]bool{"catchall.example.net": true},
+		rcpt: map[string]int{
+			"<john@example.com>":          250,
+			`<"john smith"@example.com>`:  250,
+			"<busy@example.com>":          451,
+			"<jane@implicit.example.org>": 250,
+			"<john@[192.0.2.1]>":          250,
+			`<"jörg"@example.com>`:        250,
+		},
+	}
+
+	v := NewCalloutVerifier(s, fr, "verifier.example.com")
+	v.probeLocalPart = func() string { return "probe" }
+	return v, s
+}
+
+func TestCalloutVerify(t *testing.T) {
+	t.Parallel()
+
+	v, s := newCalloutTest()
+	ctx := context.Background()
+
+	tests := []struct {
+		in     string
+		status CalloutStatus
+		host   string
+	}{
+		{"john@example.com", CalloutAccepted, "mx.example.com"},
+		{`"john smith"@example.com`, CalloutAccepted, "mx.example.com"},
+		{"nobody@example.com", CalloutRejected, "mx.example.com"},
+		{"busy@example.com", CalloutTempFail, "mx.example.com"},
+		{"anyone@catchall.example.net", CalloutCatchAll, "mx.catchall.example.net"},
+		{"jane@implicit.example.org", CalloutAccepted, "implicit.example.org"},
+		{"john@[192.0.2.1]", CalloutAccepted, "192.0.2.1"},
+		{"john@null.example.org", CalloutNoMailHost, ""},
+		{"john@mail.test", CalloutNoMailHost, ""},
+	}
+
+	for _, tc := range tests {
+		as, err := ParseEmailAddrSpec(tc.in)
+		if !assert.NoError(t, err, tc.in) {
+			continue
+		}
+
+		r := v.Verify(ctx, as)
+		assert.NoError(t, r.Err, tc.in)
+		assert.Equal(t, tc.status, r.Status, tc.in)
+		assert.Equal(t, tc.host, r.Host, tc.in)
+		assert.Same(t, as, r.AddrSpec, tc.in)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	assert.Equal(t, []string{
+		"EHLO verifier.example.com",
+		"MAIL FROM:<>",
+		"RCPT TO:<john@example.com>",
+		"RCPT TO:<probe@example.com>",
+		"QUIT",
+	}, s.commands[:5])
+}
+
+func TestCalloutVerifyErrors(t *testing.T) {
+	t.Parallel()
+
+	v, s := newCalloutTest()
+	ctx := context.Background()
+
+	s.hosts["mx.example.com"] = false
+	r := v.Verify(ctx, NewAddrSpec("john", "example.com"))
+	assert.Equal(t, CalloutUnknown, r.Status)
+	assert.EqualError(t, r.Err, "connection refused")
+
+	r = v.Verify(ctx, NewAddrSpec("john", "broken.example.org"))
+	assert.Equal(t, CalloutUnknown, r.Status)
+	assert.Error(t, r.Err)
+
+	s.noEHLO = true
+	r = v.Verify(ctx, NewAddrSpec("jane", "implicit.example.org"))
+	assert.Equal(t, CalloutAccepted, r.Status)
+	assert.Equal(t, 250, r.Code)
+}
+
+func TestCalloutVerifyUnsafe(t *testing.T) {
+	t.Parallel()
+
+	v, s := newCalloutTest()
+	ctx := context.Background()
+
+	as, err := ParseEmailAddrSpec("\"a\\\r\\\nDATA\"@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "a\r\nDATA", as.LocalPart())
+
+	for _, a := range []*AddrSpec{as, NewAddrSpec("a\r\nDATA", "example.com"), NewAddrSpec("a\x00", "example.com")} {
+		r := v.Verify(ctx, a)
+		assert.Equal(t, CalloutUnknown, r.Status)
+		assert.Equal(t, ErrUnsafeCommand, r.Err)
+		assert.Equal(t, "", r.Host)
+	}
+
+	v.Hostname = "verifier.example.com\r\nDATA"
+	r := v.Verify(ctx, NewAddrSpec("john", "example.com"))
+	assert.Equal(t, ErrUnsafeCommand, r.Err)
+
+	v.Hostname = "vérifier.example.com"
+	r = v.Verify(ctx, NewAddrSpec("john", "example.com"))
+	assert.Equal(t, ErrUnsafeCommand, r.Err)
+
+	s.lock.Lock()
+	assert.Empty(t, s.commands)
+	s.lock.Unlock()
+}
+
+func TestCalloutVerifySMTPUTF8(t *testing.T) {
+	t.Parallel()
+
+	v, s := newCalloutTest()
+	ctx := context.Background()
+	as := NewAddrSpec("jörg", "example.com")
+
+	r := v.Verify(ctx, as)
+	assert.Equal(t, CalloutUnknown, r.Status)
+	assert.Equal(t, ErrSMTPUTF8Required, r.Err)
+	assert.Equal(t, "mx.example.com", r.Host)
+
+	s.lock.Lock()
+	s.smtputf8 = true
+	s.commands = nil
+	s.lock.Unlock()
+
+	v.DetectCatchAll = false
+	r = v.Verify(ctx, as)
+	assert.NoError(t, r.Err)
+	assert.Equal(t, CalloutAccepted, r.Status)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	assert.Equal(t, []string{
+		"EHLO verifier.example.com",
+		"MAIL FROM:<> SMTPUTF8",
+		`RCPT TO:<"jörg"@example.com>`,
+		"QUIT",
+	}, s.commands)
+}
+
+func TestCalloutVerifyList(t *testing.T) {
+	t.Parallel()
+
+	v, _ := newCalloutTest()
+	v.Concurrency = 2
+
+	al := mustParseList(t, "john@example.com, Team: nobody@example.com, busy@example.com;, jane@implicit.example.org")
+	rs := v.VerifyList(context.Background(), al)
+
+	var got []string
+	for _, r := range rs {
+		got = append(got, r.AddrSpec.CleanString()+" "+r.Status.String())
+	}
+
+	assert.Equal(t, []string{
+		"john@example.com accepted",
+		"nobody@example.com rejected",
+		"busy@example.com temporary failure",
+		"jane@implicit.example.org accepted",
+	}, got)
+}
+
+func TestCalloutRateLimit(t *testing.T) {
+	t.Parallel()
+
+	v, _ := newCalloutTest()
+	v.Interval = 50 * time.Millisecond
+
+	start := time.Now()
+	al := mustParseList(t, "john@example.com, nobody@example.com, jane@implicit.example.org")
+	rs := v.VerifyList(context.Background(), al)
+	assert.Len(t, rs, 3)
+	assert.True(t, time.Since(start) >= 2*v.Interval)
+}
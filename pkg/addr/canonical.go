@@ -0,0 +1,99 @@
+package addr
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/text/unicode/norm"
+)
+
+// idnaProfile converts domains to their ASCII form. It applies the UTS #46
+// mapping used for lookup, which also lowercases the domain, but it permits
+// characters outside of the hostname rules (e.g., "_") as these can appear in
+// the domain of an email address.
+var idnaProfile = idna.New(
+	idna.MapForLookup(),
+	idna.Transitional(false),
+	idna.StrictDomainName(false),
+)
+
+// IDNAError is returned by Canonicalize when the domain cannot be converted to
+// its ASCII IDNA form (e.g., because it contains a disallowed character or an
+// invalid punycode label).
+type IDNAError struct {
+	Domain string // the domain that could not be converted
+	Err    error  // the error returned by the IDNA conversion
+}
+
+// Error returns a message describing the domain and the conversion failure.
+func (e *IDNAError) Error() string {
+	return fmt.Sprintf("unable to convert domain %q to IDNA: %v", e.Domain, e.Err)
+}
+
+// Unwrap returns the error returned by the IDNA conversion.
+func (e *IDNAError) Unwrap() error { return e.Err }
+
+// canonicalDomain returns the domain lowercased and in the ASCII IDNA form. A
+// domain literal is only lowercased.
+func canonicalDomain(d string) (string, error) {
+	if strings.HasPrefix(d, "[") {
+		return strings.ToLower(d), nil
+	}
+
+	a, err := idnaProfile.ToASCII(d)
+	if err != nil {
+		return "", &IDNAError{d, err}
+	}
+
+	return a, nil
+}
+
+// canonicalText returns the string in NFC with each run of whitespace replaced
+// by a single space and leading and trailing whitespace removed.
+func canonicalText(s string) string {
+	return strings.Join(strings.Fields(norm.NFC.String(s)), " ")
+}
+
+// Canonicalize returns a new AddrSpec holding the canonical form of this
+// address. The domain is lowercased and converted to the ASCII IDNA form (i.e.,
+// any internationalized labels are written as punycode "xn--" labels) and the
+// local part is normalized to NFC. The case of the local part is kept, as only
+// the receiving host may decide if it is significant. The result has no
+// original string or comments, so its CleanString never quotes a local part
+// that does not need it, e.g., "\"john\"@EXAMPLE.com" becomes
+// "john@example.com".
+//
+// The canonical form is deterministic, so the CleanString of the result is
+// suitable for use as a key for storing or comparing addresses.
+//
+// An *IDNAError is returned if the domain cannot be converted.
+func (as *AddrSpec) Canonicalize() (*AddrSpec, error) {
+	d, err := canonicalDomain(as.domain)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewAddrSpec(norm.NFC.String(as.localPart), d), nil
+}
+
+// Canonicalize returns a new Mailbox holding the canonical form of this
+// mailbox. The AddrSpec is canonicalized as described for
+// AddrSpec.Canonicalize. The display name and comment are normalized to NFC,
+// each run of whitespace within them is replaced by a single space, and leading
+// and trailing whitespace is removed. The result has no original string and
+// the comment is kept as a single comment at the end of the mailbox.
+//
+// An *IDNAError is returned if the domain cannot be converted.
+func (m *Mailbox) Canonicalize() (*Mailbox, error) {
+	as, err := m.address.Canonicalize()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Mailbox{
+		displayName: canonicalText(m.displayName),
+		address:     as,
+		comments:    mailboxComments(canonicalText(m.Comment())),
+	}, nil
+}
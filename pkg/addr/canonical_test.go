@@ -0,0 +1,89 @@
+package addr
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddrSpecCanonicalize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in, out string
+	}{
+		{`"john"@example.com`, "john@example.com"},
+		{"John.Smith@EXAMPLE.Com", "John.Smith@example.com"},
+		{`"john smith"@example.com`, `"john smith"@example.com`},
+		{"user@xn--bcher-kva.example", "user@xn--bcher-kva.example"},
+		{"user@[IPv6:2001:DB8::1]", "user@[ipv6:2001:db8::1]"},
+		{"user@obs . EXAMPLE . com", "user@obs.example.com"},
+		{"user@under_score.example", "user@under_score.example"},
+	}
+
+	for _, tc := range tests {
+		as, err := ParseEmailAddrSpec(tc.in)
+		if !assert.NoError(t, err, tc.in) {
+			continue
+		}
+
+		c, err := as.Canonicalize()
+		assert.NoError(t, err, tc.in)
+		assert.Equal(t, tc.out, c.CleanString(), tc.in)
+		assert.Equal(t, "", c.OriginalString(), tc.in)
+
+		// canonicalization is idempotent
+		cc, err := c.Canonicalize()
+		assert.NoError(t, err, tc.in)
+		assert.Equal(t, c, cc, tc.in)
+	}
+}
+
+func TestAddrSpecCanonicalizeUnicode(t *testing.T) {
+	t.Parallel()
+
+	c, err := NewAddrSpec("cafe\u0301", "BU\u0308CHER.example").Canonicalize()
+	assert.NoError(t, err)
+	assert.Equal(t, "caf\u00e9", c.LocalPart())
+	assert.Equal(t, "xn--bcher-kva.example", c.Domain())
+
+	c, err = NewAddrSpec("caf\u00e9", "b\u00fccher.example").Canonicalize()
+	assert.NoError(t, err)
+	assert.Equal(t, "caf\u00e9", c.LocalPart())
+	assert.Equal(t, "xn--bcher-kva.example", c.Domain())
+}
+
+func TestAddrSpecCanonicalizeError(t *testing.T) {
+	t.Parallel()
+
+	as := NewAddrSpec("user", "xn--a.example")
+	c, err := as.Canonicalize()
+	assert.Nil(t, c)
+
+	var ie *IDNAError
+	if assert.True(t, errors.As(err, &ie)) {
+		assert.Equal(t, "xn--a.example", ie.Domain)
+		assert.NotNil(t, errors.Unwrap(err))
+	}
+}
+
+func TestMailboxCanonicalize(t *testing.T) {
+	t.Parallel()
+
+	mb, err := NewMailboxStr("  Jose\u0301   Smith ", `"jose"@Example.COM`, "  the   \t boss ")
+	assert.NoError(t, err)
+
+	c, err := mb.Canonicalize()
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Jos\u00e9 Smith", c.DisplayName())
+	assert.Equal(t, "the boss", c.Comment())
+	assert.Equal(t, "=?utf-8?q?Jos=C3=A9_Smith?= <jose@example.com> (the boss)", c.CleanString())
+	assert.Equal(t, []Comment{
+		{CommentAfterAngleAddr, "the boss", "the boss"},
+	}, c.Comments())
+
+	// the original is not modified
+	assert.Equal(t, "Example.COM", mb.Domain())
+}
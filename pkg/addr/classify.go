@@ -0,0 +1,265 @@
+package addr
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// AddressClass is a set of labels describing what kind of address an AddrSpec
+// is. An address may have more than one, e.g., "info@gmail.com" is both a role
+// account and at a free mail provider.
+type AddressClass int
+
+// These are the address classes.
+const (
+	// ClassRole marks an address for a role or department rather than a
+	// person, such as "postmaster", "abuse", "info", or "sales".
+	ClassRole AddressClass = 1 << iota
+
+	// ClassNoReply marks an address that does not accept replies, such as
+	// "no-reply" or a bounce address.
+	ClassNoReply
+
+	// ClassDisposable marks an address at a disposable mail service.
+	ClassDisposable
+
+	// ClassFreeMail marks an address at a free mail provider.
+	ClassFreeMail
+
+	// ClassCorporate marks an address at a registrable domain that is neither
+	// a free mail provider nor a disposable mail service, which is presumably
+	// owned by the organization of the person using it.
+	ClassCorporate
+)
+
+var addressClassNames = []string{
+	"role",
+	"no-reply",
+	"disposable",
+	"free mail",
+	"corporate",
+}
+
+// Has returns true if the set includes every class of c.
+func (ac AddressClass) Has(c AddressClass) bool {
+	return ac&c == c
+}
+
+// String returns the names of the classes in the set separated by commas.
+func (ac AddressClass) String() string {
+	var ns []string
+	for i, n := range addressClassNames {
+		if ac&(1<<i) != 0 {
+			ns = append(ns, n)
+		}
+	}
+	return strings.Join(ns, ", ")
+}
+
+// builtinClassData is the data each new Classifier starts with. Local parts
+// ending with "*" match any local part starting with what comes before it.
+var builtinClassData = map[AddressClass][]string{
+	ClassRole: {
+		"abuse", "accounting", "accounts", "admin", "administrator", "billing",
+		"contact", "customerservice", "enquiries", "feedback", "help",
+		"helpdesk", "hello", "hostmaster", "hr", "info", "inquiries", "jobs",
+		"legal", "marketing", "media", "office", "orders", "postmaster",
+		"press", "privacy", "root", "sales", "security", "service", "support",
+		"team", "webmaster",
+	},
+	ClassNoReply: {
+		"bounce", "bounces", "bounce-*", "bounces-*", "donotreply",
+		"do-not-reply", "do_not_reply", "mailer-daemon", "noreply",
+		"no-reply", "no_reply", "noreply-*", "no-reply-*", "notifications",
+	},
+	ClassDisposable: {
+		"10minutemail.com", "discard.email", "dispostable.com",
+		"emailondeck.com", "getnada.com", "guerrillamail.com",
+		"guerrillamail.net", "mailcatch.com", "maildrop.cc", "mailinator.com",
+		"mailnesia.com", "mintemail.com", "mohmal.com", "sharklasers.com",
+		"spamgourmet.com", "temp-mail.org", "tempmail.net", "throwawaymail.com",
+		"trashmail.com", "yopmail.com",
+	},
+	ClassFreeMail: {
+		"aol.com", "fastmail.com", "gmail.com", "gmx.com", "gmx.de",
+		"googlemail.com", "hotmail.com", "icloud.com", "live.com", "mac.com",
+		"mail.com", "mail.ru", "me.com", "msn.com", "outlook.com",
+		"proton.me", "protonmail.com", "qq.com", "web.de", "yahoo.com",
+		"yandex.com", "yandex.ru", "zoho.com",
+	},
+}
+
+// Classifier labels addresses using lists of role and no-reply local parts and
+// of disposable and free mail domains. It is safe for concurrent use.
+type Classifier struct {
+	// DetailSeparator separates the user from the detail of a subaddress.
+	// Local parts are matched using only the user part, so
+	// "postmaster+abuse@example.com" is a role account. See
+	// AddrSpec.Subaddress.
+	DetailSeparator string
+
+	lock  sync.RWMutex
+	lists map[AddressClass]map[string]struct{}
+}
+
+// DefaultClassifier is the classifier used by Classify. It starts with the
+// built-in data, which may be replaced at any time using Load.
+var DefaultClassifier = NewClassifier()
+
+// NewClassifier returns a classifier that starts with the built-in data and
+// uses DefaultDetailSeparator.
+func NewClassifier() *Classifier {
+	c := &Classifier{
+		DetailSeparator: DefaultDetailSeparator,
+		lists:           map[AddressClass]map[string]struct{}{},
+	}
+
+	for class, entries := range builtinClassData {
+		c.Set(class, entries)
+	}
+
+	return c
+}
+
+// classListKey returns the form of a list entry used for matching.
+func classListKey(class AddressClass, entry string) string {
+	if class == ClassDisposable || class == ClassFreeMail {
+		return domainKey(entry)
+	}
+
+	return strings.ToLower(entry)
+}
+
+// Set replaces the list for the class, which must be one of ClassRole,
+// ClassNoReply, ClassDisposable, or ClassFreeMail. Role and no-reply entries
+// are local parts, which match any local part when they end with "*". The
+// other entries are domains, which also match their subdomains.
+func (c *Classifier) Set(class AddressClass, entries []string) error {
+	if _, ok := builtinClassData[class]; !ok {
+		return fmt.Errorf("address class %q has no list", class)
+	}
+
+	l := make(map[string]struct{}, len(entries))
+	for _, e := range entries {
+		l[classListKey(class, e)] = struct{}{}
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.lists[class] = l
+	return nil
+}
+
+// Read replaces the list for the class with the entries read from r, one per
+// line. Blank lines and lines starting with "#" are ignored. See Set.
+func (c *Classifier) Read(class AddressClass, r io.Reader) error {
+	var entries []string
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		e := strings.TrimSpace(s.Text())
+		if e == "" || strings.HasPrefix(e, "#") {
+			continue
+		}
+		entries = append(entries, e)
+	}
+
+	if err := s.Err(); err != nil {
+		return err
+	}
+
+	return c.Set(class, entries)
+}
+
+// Load replaces the list for the class with the entries read from the named
+// file. See Read.
+func (c *Classifier) Load(class AddressClass, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return c.Read(class, f)
+}
+
+// matchLocalPart returns true if the user matches an entry of the list.
+func (c *Classifier) matchLocalPart(class AddressClass, user string) bool {
+	l := c.lists[class]
+	if _, ok := l[user]; ok {
+		return true
+	}
+
+	for e := range l {
+		if strings.HasSuffix(e, "*") && strings.HasPrefix(user, e[:len(e)-1]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchDomain returns true if the domain or any domain it is under appears in
+// the list.
+func (c *Classifier) matchDomain(class AddressClass, d string) bool {
+	l := c.lists[class]
+	for {
+		if _, ok := l[d]; ok {
+			return true
+		}
+
+		i := strings.Index(d, ".")
+		if i < 0 {
+			return false
+		}
+		d = d[i+1:]
+	}
+}
+
+// Classify returns the classes of the address. The local part is matched
+// case-insensitively using only its user part. Domains are matched in their
+// canonical form.
+func (c *Classifier) Classify(as *AddrSpec) AddressClass {
+	user := strings.ToLower(as.User(c.DetailSeparator))
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	var ac AddressClass
+	if c.matchLocalPart(ClassRole, user) {
+		ac |= ClassRole
+	}
+
+	if c.matchLocalPart(ClassNoReply, user) {
+		ac |= ClassNoReply
+	}
+
+	if strings.HasPrefix(as.domain, "[") {
+		return ac
+	}
+
+	d := domainKey(as.domain)
+	if c.matchDomain(ClassDisposable, d) {
+		ac |= ClassDisposable
+	}
+
+	if c.matchDomain(ClassFreeMail, d) {
+		ac |= ClassFreeMail
+	}
+
+	if ac&(ClassDisposable|ClassFreeMail) == 0 && registrableDomain(d) != "" {
+		ac |= ClassCorporate
+	}
+
+	return ac
+}
+
+// Classify returns the classes of the address using DefaultClassifier. See
+// Classifier.Classify.
+func Classify(as *AddrSpec) AddressClass {
+	return DefaultClassifier.Classify(as)
+}
@@ -0,0 +1,72 @@
+package addr
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in    string
+		class AddressClass
+	}{
+		{"john@example.com", ClassCorporate},
+		{"Postmaster@example.com", ClassRole | ClassCorporate},
+		{"support+billing@example.com", ClassRole | ClassCorporate},
+		{"info@gmail.com", ClassRole | ClassFreeMail},
+		{"jane.doe@GoogleMail.com", ClassFreeMail},
+		{"no-reply@example.com", ClassNoReply | ClassCorporate},
+		{"bounces+abc123@mail.example.com", ClassNoReply | ClassCorporate},
+		{"bounce-xyz@example.com", ClassNoReply | ClassCorporate},
+		{"throwaway@mailinator.com", ClassDisposable},
+		{"throwaway@eu.mailinator.com", ClassDisposable},
+		{"john@localhost", 0},
+		{"abuse@[192.0.2.1]", ClassRole},
+	}
+
+	for _, tc := range tests {
+		as, err := ParseEmailAddrSpec(tc.in)
+		if !assert.NoError(t, err, tc.in) {
+			continue
+		}
+
+		assert.Equal(t, tc.class, Classify(as), tc.in)
+	}
+}
+
+func TestClassifierRead(t *testing.T) {
+	t.Parallel()
+
+	c := NewClassifier()
+	c.DetailSeparator = "-"
+
+	err := c.Read(ClassDisposable, strings.NewReader("# our own list\n\nburner.example\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, c.Set(ClassRole, []string{"sec*", "ops"}))
+
+	assert.Equal(t, ClassDisposable, c.Classify(NewAddrSpec("john", "burner.example")))
+	assert.Equal(t, ClassCorporate, c.Classify(NewAddrSpec("john", "mailinator.com")))
+	assert.Equal(t, ClassRole|ClassCorporate, c.Classify(NewAddrSpec("ops-east", "example.com")))
+	assert.Equal(t, ClassCorporate, c.Classify(NewAddrSpec("info", "example.com")))
+	assert.Equal(t, ClassRole|ClassCorporate, c.Classify(NewAddrSpec("SecOps", "example.com")))
+
+	// the default classifier is not changed
+	assert.Equal(t, ClassDisposable, Classify(NewAddrSpec("john", "mailinator.com")))
+
+	assert.Error(t, c.Set(ClassCorporate, []string{"example.com"}))
+	assert.Error(t, c.Load(ClassRole, "/no/such/file"))
+}
+
+func TestAddressClassString(t *testing.T) {
+	t.Parallel()
+
+	ac := ClassRole | ClassFreeMail
+	assert.Equal(t, "role, free mail", ac.String())
+	assert.True(t, ac.Has(ClassRole))
+	assert.False(t, ac.Has(ClassRole|ClassNoReply))
+	assert.Equal(t, "", AddressClass(0).String())
+}
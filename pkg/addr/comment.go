@@ -0,0 +1,321 @@
+package addr
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/zostay/go-addr/pkg/format"
+	"github.com/zostay/go-addr/pkg/rd"
+	p "github.com/zostay/go-addr/pkg/rfc5322"
+)
+
+// CommentPosition identifies where a comment was found in an email address.
+// The positions are ordered as they appear in the text of an address.
+type CommentPosition int
+
+// These are the positions that a comment may be found at.
+const (
+	// CommentBeforeDisplayName is before the display name of a mailbox or
+	// group.
+	CommentBeforeDisplayName CommentPosition = iota
+
+	// CommentInDisplayName is between the words of a display name.
+	CommentInDisplayName
+
+	// CommentAfterDisplayName is after the display name, i.e., before the "<"
+	// of a mailbox or the ":" of a group.
+	CommentAfterDisplayName
+
+	// CommentBeforeAngleAddr is before the "<" of a mailbox with no display
+	// name.
+	CommentBeforeAngleAddr
+
+	// CommentInRoute is within an obsolete source route.
+	CommentInRoute
+
+	// CommentBeforeLocalPart is before the local part of an address.
+	CommentBeforeLocalPart
+
+	// CommentInLocalPart is between the words of an obsolete local part.
+	CommentInLocalPart
+
+	// CommentAfterLocalPart is after the local part, i.e., before the "@".
+	CommentAfterLocalPart
+
+	// CommentBeforeDomain is after the "@" and before the domain.
+	CommentBeforeDomain
+
+	// CommentInDomain is between the parts of an obsolete domain.
+	CommentInDomain
+
+	// CommentAfterDomain is after the domain. For a mailbox with no angle
+	// brackets, this is at the end of the mailbox.
+	CommentAfterDomain
+
+	// CommentAfterAngleAddr is after the ">" at the end of a mailbox. This is
+	// also the position given to a comment set by NewMailbox or SetComment.
+	CommentAfterAngleAddr
+
+	// CommentInGroupList is after the ":" of a group, but not within any of
+	// its mailboxes.
+	CommentInGroupList
+
+	// CommentAfterGroup is after the ";" at the end of a group.
+	CommentAfterGroup
+)
+
+var commentPositionNames = []string{
+	"before display name",
+	"in display name",
+	"after display name",
+	"before angle address",
+	"in route",
+	"before local part",
+	"in local part",
+	"after local part",
+	"before domain",
+	"in domain",
+	"after domain",
+	"after angle address",
+	"in group list",
+	"after group",
+}
+
+// String returns a short description of the position.
+func (cp CommentPosition) String() string {
+	if cp < 0 || int(cp) >= len(commentPositionNames) {
+		return "unknown"
+	}
+
+	return commentPositionNames[cp]
+}
+
+// Comment is a single comment found within an email address.
+type Comment struct {
+	Position CommentPosition // where the comment was found
+	Raw      string          // the text between the parentheses as written
+	Text     string          // the text with quoted pairs and MIME words decoded
+}
+
+// newComment creates a comment at the given position for comment text that
+// was not parsed. The raw text is the text as CleanString would render it.
+func newComment(pos CommentPosition, text string) Comment {
+	raw := format.RenderComment(text)
+	return Comment{
+		Position: pos,
+		Raw:      raw[1 : len(raw)-1],
+		Text:     text,
+	}
+}
+
+// madeComment creates a comment from a comment match.
+func madeComment(m *rd.Match, pos CommentPosition) Comment {
+	raw := m.Group["comment-content"].Content
+	return Comment{
+		Position: pos,
+		Raw:      string(raw),
+		Text:     decodeMIMEWords(string(unquotePairs(raw))),
+	}
+}
+
+// joinComments returns the text of the comments joined with spaces.
+func joinComments(cs []Comment) string {
+	ts := make([]string, len(cs))
+	for i, c := range cs {
+		ts[i] = c.Text
+	}
+	return strings.Join(ts, " ")
+}
+
+// dropComments returns the comments that are not at any of the given
+// positions.
+func dropComments(cs []Comment, ps ...CommentPosition) []Comment {
+	var keep []Comment
+	for _, c := range cs {
+		drop := false
+		for _, p := range ps {
+			if c.Position == p {
+				drop = true
+				break
+			}
+		}
+		if !drop {
+			keep = append(keep, c)
+		}
+	}
+	return keep
+}
+
+// sortComments puts the comments in textual order by position.
+func sortComments(cs []Comment) []Comment {
+	sort.SliceStable(cs, func(i, j int) bool { return cs[i].Position < cs[j].Position })
+	return cs
+}
+
+// flattenComments returns the comments and other content of the match in
+// textual order. Each comment is returned as its match. Each run of other
+// content that is not whitespace is returned as a single nil.
+func flattenComments(m *rd.Match, out []*rd.Match) []*rd.Match {
+	if m == nil {
+		return out
+	}
+
+	if m.Tag == p.TComment {
+		return append(out, m)
+	}
+
+	if len(m.Submatch) == 0 {
+		if strings.IndexFunc(string(m.Content), func(c rune) bool { return !unicode.IsSpace(c) }) > -1 {
+			if len(out) == 0 || out[len(out)-1] != nil {
+				out = append(out, nil)
+			}
+		}
+		return out
+	}
+
+	for _, sm := range m.Submatch {
+		out = flattenComments(sm, out)
+	}
+
+	return out
+}
+
+// regionComments returns the comments within the match. Comments found before
+// any other content are given the before position, comments after all other
+// content are given the after position, and all others are given the in
+// position.
+func regionComments(m *rd.Match, before, in, after CommentPosition) []Comment {
+	flat := flattenComments(m, nil)
+
+	first, last := -1, -1
+	for i, c := range flat {
+		if c == nil {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+
+	var cs []Comment
+	for i, c := range flat {
+		if c == nil {
+			continue
+		}
+
+		pos := in
+		if first < 0 || i < first {
+			pos = before
+		} else if i > last {
+			pos = after
+		}
+
+		cs = append(cs, madeComment(c, pos))
+	}
+
+	return cs
+}
+
+// angleAddrComments returns the comments of an angle address match, except
+// for those within the addr-spec, which belong to the AddrSpec.
+func angleAddrComments(m *rd.Match) []Comment {
+	var cs []Comment
+	pos := CommentBeforeAngleAddr
+	for _, sm := range m.Submatch {
+		switch sm {
+		case m.Group["obs-route"]:
+			cs = append(cs, regionComments(sm, CommentInRoute, CommentInRoute, CommentInRoute)...)
+		case m.Group["addr-spec"]:
+			pos = CommentAfterAngleAddr
+		default:
+			cs = append(cs, regionComments(sm, pos, pos, pos)...)
+		}
+	}
+	return cs
+}
+
+// groupListComments returns the comments within a group list match, except
+// for those within a mailbox, which belong to the Mailbox.
+func groupListComments(m *rd.Match, cs []Comment) []Comment {
+	if m == nil {
+		return cs
+	}
+
+	switch m.Made.(type) {
+	case *Mailbox, *AddrSpec:
+		return cs
+	}
+
+	if m.Tag == p.TComment {
+		return append(cs, madeComment(m, CommentInGroupList))
+	}
+
+	for _, sm := range m.Submatch {
+		cs = groupListComments(sm, cs)
+	}
+
+	return cs
+}
+
+// mailboxComments returns the comments for a mailbox constructed with the given
+// comment text.
+func mailboxComments(c string) []Comment {
+	if c == "" {
+		return nil
+	}
+
+	return []Comment{newComment(CommentAfterAngleAddr, c)}
+}
+
+// Comments returns every comment of the mailbox in textual order, including
+// the comments within its AddrSpec.
+func (m *Mailbox) Comments() []Comment {
+	cs := make([]Comment, 0, len(m.comments))
+	cs = append(cs, m.comments...)
+	cs = append(cs, m.address.Comments()...)
+	return sortComments(cs)
+}
+
+// TrailingComments returns the comments found at the end of the mailbox. This
+// is where legacy mail often stores the real name of the owner of the address,
+// e.g., "ender@example.com (Andrew Wiggin)". These are the comments after the
+// ">" or, if there are no angle brackets, after the domain.
+func (m *Mailbox) TrailingComments() []Comment {
+	var cs []Comment
+	for _, c := range m.Comments() {
+		if c.Position == CommentAfterAngleAddr {
+			cs = append(cs, c)
+		}
+	}
+
+	if len(cs) > 0 || !m.bareAddrSpec() {
+		return cs
+	}
+
+	for _, c := range m.address.Comments() {
+		if c.Position == CommentAfterDomain {
+			cs = append(cs, c)
+		}
+	}
+
+	return cs
+}
+
+// bareAddrSpec returns true if the mailbox was parsed from or would be written
+// as an addr-spec without angle brackets.
+func (m *Mailbox) bareAddrSpec() bool {
+	if m.original != "" {
+		return m.original == m.address.original
+	}
+
+	return m.displayName == ""
+}
+
+// Comments returns the comments found within the address in textual order.
+func (as *AddrSpec) Comments() []Comment { return as.comments }
+
+// Comments returns the comments of the group in textual order. This includes
+// the comments around the display name, within the group list, and after the
+// end of the group, but not those of the mailboxes within the group.
+func (g *Group) Comments() []Comment { return g.comments }
@@ -0,0 +1,153 @@
+package addr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMailboxComments(t *testing.T) {
+	t.Parallel()
+
+	mb, err := ParseEmailMailbox(`(a) John (b) Smith (c) <(d) john (e) @ (f) example.com (g)> (h)`)
+	assert.NoError(t, err)
+
+	cs := mb.Comments()
+	assert.Equal(t, []Comment{
+		{CommentBeforeDisplayName, "a", "a"},
+		{CommentInDisplayName, "b", "b"},
+		{CommentAfterDisplayName, "c", "c"},
+		{CommentBeforeLocalPart, "d", "d"},
+		{CommentAfterLocalPart, "e", "e"},
+		{CommentBeforeDomain, "f", "f"},
+		{CommentAfterDomain, "g", "g"},
+		{CommentAfterAngleAddr, "h", "h"},
+	}, cs)
+
+	assert.Equal(t, "a b c d e f g h", mb.Comment())
+	assert.Equal(t, "d e f g", mb.AddrSpec().Comment())
+	assert.Equal(t, []Comment{{CommentAfterAngleAddr, "h", "h"}}, mb.TrailingComments())
+}
+
+func TestMailboxCommentsDecoded(t *testing.T) {
+	t.Parallel()
+
+	mb, err := ParseEmailMailbox(`(=?utf-8?q?caf=C3=A9?=) <ender@example.com> (not \(really\) here)`)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Comment{
+		{CommentBeforeAngleAddr, "=?utf-8?q?caf=C3=A9?=", "café"},
+		{CommentAfterAngleAddr, `not \(really\) here`, "not (really) here"},
+	}, mb.Comments())
+}
+
+func TestMailboxTrailingComments(t *testing.T) {
+	t.Parallel()
+
+	mb, err := ParseEmailMailbox("ender@example.com (Andrew Wiggin)")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Comment{
+		{CommentAfterDomain, "Andrew Wiggin", "Andrew Wiggin"},
+	}, mb.TrailingComments())
+
+	mb, err = ParseEmailMailbox("<ender@example.com (routing)>")
+	assert.NoError(t, err)
+
+	assert.Empty(t, mb.TrailingComments())
+	assert.Equal(t, "routing", mb.Comment())
+
+	mb, err = NewMailboxStr("", "ender@example.com", "Andrew Wiggin")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Comment{
+		{CommentAfterAngleAddr, "Andrew Wiggin", "Andrew Wiggin"},
+	}, mb.TrailingComments())
+
+	mb.SetComment("Ender (Third)")
+
+	assert.Equal(t, []Comment{
+		{CommentAfterAngleAddr, "Ender (Third)", "Ender (Third)"},
+	}, mb.Comments())
+}
+
+func TestObsRouteComments(t *testing.T) {
+	t.Parallel()
+
+	mb, err := ParseEmailMailbox("<@(hop) example.net:ender@example.com>")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Comment{
+		{CommentInRoute, "hop", "hop"},
+	}, mb.Comments())
+}
+
+func TestGroupComments(t *testing.T) {
+	t.Parallel()
+
+	g, err := ParseEmailGroup("(a) Friends (b): (c) ender@example.com (d), bean@example.com; (e)")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Comment{
+		{CommentBeforeDisplayName, "a", "a"},
+		{CommentAfterDisplayName, "b", "b"},
+		{CommentAfterGroup, "e", "e"},
+	}, g.Comments())
+	assert.Equal(t, "a b e", g.Comment())
+
+	assert.Equal(t, []Comment{
+		{CommentBeforeLocalPart, "c", "c"},
+		{CommentAfterDomain, "d", "d"},
+	}, g.MailboxList()[0].Comments())
+
+	g.SetDisplayName("Enemies")
+
+	assert.Equal(t, []Comment{
+		{CommentAfterGroup, "e", "e"},
+	}, g.Comments())
+}
+
+func TestAddrSpecComments(t *testing.T) {
+	t.Parallel()
+
+	as, err := ParseEmailAddrSpec("ender (one) @ (two) example.com")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "one two", as.Comment())
+
+	as.SetDomain("example.net")
+	assert.Equal(t, []Comment{
+		{CommentAfterLocalPart, "one", "one"},
+	}, as.Comments())
+
+	as.SetLocalPart("bean")
+	assert.Empty(t, as.Comments())
+	assert.Equal(t, "", as.Comment())
+}
+
+func TestSetCommentAfterParse(t *testing.T) {
+	t.Parallel()
+
+	mb, err := ParseEmailMailbox("(before) John <john(local)@example.com> (trailing)")
+	assert.NoError(t, err)
+	as := mb.AddrSpec()
+	assert.Equal(t, "before local trailing", mb.Comment())
+
+	mb.SetComment("new")
+	assert.Equal(t, "new", mb.Comment())
+	assert.Equal(t, []Comment{
+		{CommentAfterAngleAddr, "new", "new"},
+	}, mb.Comments())
+	assert.Equal(t, mb.Comments(), mb.TrailingComments())
+	assert.Equal(t, "John <john@example.com> (new)", mb.CleanString())
+
+	// the parsed AddrSpec is not modified
+	assert.Equal(t, "local", as.Comment())
+	assert.Equal(t, "", mb.AddrSpec().Comment())
+
+	mb, err = ParseEmailMailbox("john@example.com (trailing)")
+	assert.NoError(t, err)
+	mb.SetComment("")
+	assert.Empty(t, mb.Comments())
+	assert.Equal(t, "", mb.Comment())
+}
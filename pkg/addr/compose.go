@@ -0,0 +1,175 @@
+package addr
+
+import "strings"
+
+// UndisclosedRecipients is the display name of the empty group put in the To
+// field by a Composer when there is no other To address.
+const UndisclosedRecipients = "undisclosed-recipients"
+
+// BccMode selects how a Composer treats blind carbon copy recipients, following
+// the three methods described in RFC 5322 section 3.6.3.
+type BccMode int
+
+// These are the Bcc modes.
+const (
+	// BccStrip removes the Bcc field from the single copy sent to every
+	// recipient.
+	BccStrip BccMode = iota
+
+	// BccEmpty sends a single copy to every recipient with an empty Bcc field.
+	BccEmpty
+
+	// BccPerRecipient sends one copy without a Bcc field to the To and Cc
+	// recipients and a separate copy to each Bcc recipient, with a Bcc field
+	// naming only that recipient.
+	BccPerRecipient
+)
+
+// ComposedCopy is a single copy of an outgoing message.
+type ComposedCopy struct {
+	// Header holds the rendered header fields, each folded and ending with
+	// CRLF.
+	Header string
+
+	// Recipients holds the envelope recipients of the copy, i.e., the
+	// addresses to give in SMTP RCPT TO commands.
+	Recipients []*AddrSpec
+}
+
+// Composer renders the originator and destination fields of outgoing
+// messages. Each address is rendered with CleanString and each field is
+// folded as described for AddressList.FoldedHeader, so the header written
+// always parses back into the same addresses.
+type Composer struct {
+	// BccMode selects how Bcc recipients are handled. The default is
+	// BccStrip.
+	BccMode BccMode
+}
+
+// NewComposer returns a Composer using the given Bcc mode.
+func NewComposer(mode BccMode) *Composer {
+	return &Composer{BccMode: mode}
+}
+
+// envelopeAdd appends the address of every mailbox of the lists to the
+// recipients, skipping any address already present under EqualCanonical.
+func envelopeAdd(rs []*AddrSpec, seen map[string]bool, lists ...AddressList) []*AddrSpec {
+	for _, al := range lists {
+		for _, mb := range al.Flatten() {
+			if mb == nil {
+				continue
+			}
+
+			k := mb.address.KeyFor(EqualCanonical)
+			if !seen[k] {
+				seen[k] = true
+				rs = append(rs, mb.address)
+			}
+		}
+	}
+
+	return rs
+}
+
+// Envelope returns the address of every recipient in To, Cc, and Bcc,
+// including those within groups, without duplicates under EqualCanonical.
+func (c *Composer) Envelope(h *HeaderFields) []*AddrSpec {
+	return envelopeAdd(nil, map[string]bool{}, h.To, h.Cc, h.Bcc)
+}
+
+// headerWriter renders header fields, remembering the first error.
+type headerWriter struct {
+	b   strings.Builder
+	err error
+}
+
+// field renders the field if the list is not nil.
+func (w *headerWriter) field(name string, al AddressList) {
+	if al == nil || w.err != nil {
+		return
+	}
+
+	f, err := al.FoldedHeader(name)
+	if err != nil {
+		w.err = err
+		return
+	}
+
+	w.b.WriteString(f)
+	w.b.WriteString("\r\n")
+}
+
+// render returns the header fields with the given Bcc list.
+func (c *Composer) render(h *HeaderFields, bcc AddressList) (string, error) {
+	w := &headerWriter{}
+	w.field("From", h.From)
+	w.field("Sender", h.Sender)
+	w.field("Reply-To", h.ReplyTo)
+	w.field("To", h.To)
+	w.field("Cc", h.Cc)
+	w.field("Bcc", bcc)
+	return w.b.String(), w.err
+}
+
+// Compose renders the From, Sender, Reply-To, To, Cc, and Bcc fields of h as
+// the copies of an outgoing message to send. Resent fields are ignored.
+//
+// If To is empty, it is replaced by an empty group named
+// UndisclosedRecipients. Bcc is treated as selected by BccMode. When using
+// BccPerRecipient, the copy for the To and Cc recipients is left out if there
+// are none, and a Bcc recipient who is also a To or Cc recipient receives only
+// that copy.
+//
+// If the fields break any of the rules checked by HeaderFields.Validate, a
+// *ValidationError is returned. An *UnsafeTextError or format.ErrLineTooLong
+// may be returned as described for AddressList.FoldedHeader.
+func (c *Composer) Compose(h *HeaderFields) ([]ComposedCopy, error) {
+	out := *h
+	out.Resent = nil
+	if len(out.To) == 0 {
+		out.To = AddressList{NewGroupParsed(UndisclosedRecipients, nil, "")}
+	}
+
+	if err := out.Validate(); err != nil {
+		return nil, err
+	}
+
+	var bcc AddressList
+	if c.BccMode == BccEmpty {
+		bcc = AddressList{}
+	}
+
+	header, err := c.render(&out, bcc)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	if c.BccMode != BccPerRecipient {
+		return []ComposedCopy{{
+			Header:     header,
+			Recipients: envelopeAdd(nil, seen, out.To, out.Cc, out.Bcc),
+		}}, nil
+	}
+
+	var copies []ComposedCopy
+	if rs := envelopeAdd(nil, seen, out.To, out.Cc); len(rs) > 0 {
+		copies = append(copies, ComposedCopy{Header: header, Recipients: rs})
+	}
+
+	for _, mb := range out.Bcc.Flatten() {
+		rs := envelopeAdd(nil, seen, AddressList{mb})
+		if len(rs) == 0 {
+			continue
+		}
+
+		header, err := c.render(&out, AddressList{mb})
+		if err != nil {
+			return nil, err
+		}
+
+		copies = append(copies, ComposedCopy{Header: header, Recipients: rs})
+	}
+
+	return copies, nil
+}
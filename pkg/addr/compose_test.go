@@ -0,0 +1,128 @@
+package addr
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComposerCompose(t *testing.T) {
+	t.Parallel()
+
+	h := &HeaderFields{
+		From: mustParseList(t, `"Smith, John" <john@example.com>`),
+		To:   mustParseList(t, "alice@example.net, Team: bob@example.net, carol@example.net;"),
+		Cc:   mustParseList(t, "ALICE@example.net, dave@example.net"),
+		Bcc:  mustParseList(t, "eve@example.net, dave@example.net"),
+	}
+
+	cs, err := NewComposer(BccStrip).Compose(h)
+	if assert.NoError(t, err) && assert.Len(t, cs, 1) {
+		assert.Equal(t, "From: \"Smith, John\" <john@example.com>\r\n"+
+			"To: alice@example.net, Team: bob@example.net, carol@example.net;\r\n"+
+			"Cc: ALICE@example.net, dave@example.net\r\n", cs[0].Header)
+		assert.Equal(t, []string{
+			"alice@example.net",
+			"bob@example.net",
+			"carol@example.net",
+			"ALICE@example.net",
+			"dave@example.net",
+			"eve@example.net",
+		}, addrSpecStrings(cs[0].Recipients))
+	}
+
+	cs, err = NewComposer(BccEmpty).Compose(h)
+	if assert.NoError(t, err) && assert.Len(t, cs, 1) {
+		assert.Contains(t, cs[0].Header, "\r\nBcc:\r\n")
+		assert.Len(t, cs[0].Recipients, 6)
+	}
+
+	cs, err = NewComposer(BccPerRecipient).Compose(h)
+	if assert.NoError(t, err) && assert.Len(t, cs, 2) {
+		assert.NotContains(t, cs[0].Header, "Bcc:")
+		assert.Len(t, cs[0].Recipients, 5)
+		assert.Contains(t, cs[1].Header, "\r\nBcc: eve@example.net\r\n")
+		assert.Equal(t, []string{"eve@example.net"}, addrSpecStrings(cs[1].Recipients))
+	}
+
+	assert.Equal(t, []string{
+		"alice@example.net",
+		"bob@example.net",
+		"carol@example.net",
+		"ALICE@example.net",
+		"dave@example.net",
+		"eve@example.net",
+	}, addrSpecStrings(NewComposer(BccStrip).Envelope(h)))
+}
+
+func TestComposerUndisclosedRecipients(t *testing.T) {
+	t.Parallel()
+
+	h := &HeaderFields{
+		From: mustParseList(t, "john@example.com"),
+		Bcc:  mustParseList(t, "alice@example.net, bob@example.net"),
+	}
+
+	cs, err := NewComposer(BccPerRecipient).Compose(h)
+	if assert.NoError(t, err) && assert.Len(t, cs, 2) {
+		assert.Equal(t, "From: john@example.com\r\n"+
+			"To: undisclosed-recipients:;\r\n"+
+			"Bcc: alice@example.net\r\n", cs[0].Header)
+		assert.Equal(t, []string{"alice@example.net"}, addrSpecStrings(cs[0].Recipients))
+		assert.Equal(t, []string{"bob@example.net"}, addrSpecStrings(cs[1].Recipients))
+	}
+
+	// the input is left unchanged
+	assert.Nil(t, h.To)
+}
+
+func TestComposerRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	long := "Team: "
+	for i := 0; i < 20; i++ {
+		long += "member" + string(rune('a'+i)) + "@department.example.com, "
+	}
+	long = long[:len(long)-2] + ";"
+
+	jorg, err := NewMailbox("Jörg Müller", NewAddrSpec("jorg", "example.de"), "")
+	assert.NoError(t, err)
+
+	h := &HeaderFields{
+		From:    AddressList{jorg},
+		ReplyTo: mustParseList(t, `"Support (EU)" <support@example.de>`),
+		To:      mustParseList(t, long),
+		Cc:      mustParseList(t, `"quoted\"name" <q@example.com>`),
+	}
+
+	cs, err := NewComposer(BccStrip).Compose(h)
+	if !assert.NoError(t, err) || !assert.Len(t, cs, 1) {
+		return
+	}
+
+	for _, l := range strings.Split(cs[0].Header, "\r\n") {
+		assert.LessOrEqual(t, len(l), 78, l)
+	}
+
+	got, err := ParseHeaderFields(cs[0].Header)
+	if assert.NoError(t, err) {
+		assert.NoError(t, got.Validate())
+		assert.True(t, got.From[0].(*Mailbox).Equal(jorg, EqualFull))
+		assert.Equal(t, h.ReplyTo.String(), got.ReplyTo.String())
+		assert.Equal(t, h.To.String(), got.To.String())
+		assert.Equal(t, h.Cc.String(), got.Cc.String())
+	}
+}
+
+func TestComposerInvalid(t *testing.T) {
+	t.Parallel()
+
+	h := &HeaderFields{
+		From: mustParseList(t, "a@example.com, b@example.com"),
+		To:   mustParseList(t, "c@example.net"),
+	}
+
+	_, err := NewComposer(BccStrip).Compose(h)
+	assert.IsType(t, &ValidationError{}, err)
+}
@@ -0,0 +1,340 @@
+package addr
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Resolver is the interface used to look up the DNS records needed to check
+// deliverability. It is satisfied by *net.Resolver. Tests and offline
+// environments may provide an in-memory implementation instead.
+//
+// A lookup of a name that does not exist or has no records of the requested
+// type should fail with a *net.DNSError whose IsNotFound field is set. Any
+// other error is treated as temporary.
+type Resolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// DeliverabilityStatus describes whether mail can be delivered to a domain.
+type DeliverabilityStatus int
+
+// These are the deliverability statuses.
+const (
+	// DeliverabilityUnknown means the lookup failed with a temporary error.
+	DeliverabilityUnknown DeliverabilityStatus = iota
+
+	// DeliverableMX means the domain has MX records.
+	DeliverableMX
+
+	// DeliverableImplicitMX means the domain has no MX records, but does have
+	// A or AAAA records, which are used as an implicit MX as described in RFC
+	// 5321 section 5.1.
+	DeliverableImplicitMX
+
+	// UndeliverableNullMX means the domain publishes a null MX record, which
+	// states that it accepts no mail (see RFC 7505).
+	UndeliverableNullMX
+
+	// UndeliverableNoHost means the domain does not exist or has no MX, A, or
+	// AAAA records.
+	UndeliverableNoHost
+
+	// UndeliverableReservedTLD means the domain is under a top-level domain
+	// reserved by RFC 2606 or RFC 6761, such as "example" or "test". No lookup
+	// is made.
+	UndeliverableReservedTLD
+
+	// SkippedDomainLiteral means the domain is a domain literal, such as
+	// "[192.0.2.1]", which names a host directly. No lookup is made.
+	SkippedDomainLiteral
+)
+
+var deliverabilityStatusNames = []string{
+	"unknown",
+	"deliverable",
+	"deliverable by implicit MX",
+	"null MX",
+	"no mail host",
+	"reserved TLD",
+	"domain literal",
+}
+
+// String returns a short description of the status.
+func (s DeliverabilityStatus) String() string {
+	if s < 0 || int(s) >= len(deliverabilityStatusNames) {
+		return "unknown"
+	}
+
+	return deliverabilityStatusNames[s]
+}
+
+// DeliverabilityResult reports the outcome of a deliverability check of a
+// domain.
+type DeliverabilityResult struct {
+	Domain string               // the domain checked, in ASCII form
+	Status DeliverabilityStatus // the outcome of the check
+	MX     []*net.MX            // the MX records found, if any
+	Addrs  []net.IPAddr         // the addresses used as an implicit MX, if any
+}
+
+// Deliverable returns true if mail may be delivered to the domain.
+func (r *DeliverabilityResult) Deliverable() bool {
+	return r.Status == DeliverableMX || r.Status == DeliverableImplicitMX
+}
+
+// clone returns a copy of the result sharing no memory with the original.
+func (r *DeliverabilityResult) clone() *DeliverabilityResult {
+	cr := *r
+
+	if r.MX != nil {
+		cr.MX = make([]*net.MX, len(r.MX))
+		for i, mx := range r.MX {
+			cmx := *mx
+			cr.MX[i] = &cmx
+		}
+	}
+
+	if r.Addrs != nil {
+		cr.Addrs = make([]net.IPAddr, len(r.Addrs))
+		for i, a := range r.Addrs {
+			cr.Addrs[i] = net.IPAddr{IP: append(net.IP(nil), a.IP...), Zone: a.Zone}
+		}
+	}
+
+	return &cr
+}
+
+// These are the default lengths of time that a DeliverabilityChecker caches
+// results. The Resolver interface does not report the TTLs of records, so the
+// same length of time is used for every domain.
+const (
+	DefaultDeliverabilityTTL         = 1 * time.Hour
+	DefaultDeliverabilityNegativeTTL = 5 * time.Minute
+)
+
+// minDeliverabilitySweep is the number of cached results a
+// DeliverabilityChecker holds before it first removes expired results.
+const minDeliverabilitySweep = 64
+
+// deliverabilityEntry is a cached result.
+type deliverabilityEntry struct {
+	result  *DeliverabilityResult
+	expires time.Time
+}
+
+// DeliverabilityChecker checks whether mail can be delivered to the domain of
+// an address and caches the result for each domain. It is safe for concurrent
+// use.
+//
+// Expired results are removed when they are looked up and whenever the cache
+// has doubled in size since expired results were last removed, so the cache
+// stays proportional to the number of domains checked within the TTL.
+//
+// The zero value is ready to use. It uses the default resolver of the net
+// package and caches nothing, as both TTLs are zero. Use
+// NewDeliverabilityChecker for a checker with the default TTLs.
+type DeliverabilityChecker struct {
+	// Resolver is used to look up DNS records. If nil, net.DefaultResolver is
+	// used.
+	Resolver Resolver
+
+	// TTL is how long a result is cached when the domain has a mail host or a
+	// null MX.
+	TTL time.Duration
+
+	// NegativeTTL is how long a result is cached when the domain has no mail
+	// host. Results of lookups that fail with a temporary error are never
+	// cached.
+	NegativeTTL time.Duration
+
+	lock    sync.Mutex
+	cache   map[string]deliverabilityEntry
+	sweepAt int
+	now     func() time.Time
+}
+
+// DefaultDeliverabilityChecker is the checker used by CheckDeliverability. It
+// uses the default resolver of the net package.
+var DefaultDeliverabilityChecker = NewDeliverabilityChecker(net.DefaultResolver)
+
+// NewDeliverabilityChecker returns a checker that uses the given resolver and
+// the default TTLs.
+func NewDeliverabilityChecker(r Resolver) *DeliverabilityChecker {
+	return &DeliverabilityChecker{
+		Resolver:    r,
+		TTL:         DefaultDeliverabilityTTL,
+		NegativeTTL: DefaultDeliverabilityNegativeTTL,
+		now:         time.Now,
+	}
+}
+
+// resolver returns the resolver to use for lookups.
+func (c *DeliverabilityChecker) resolver() Resolver {
+	if c.Resolver == nil {
+		return net.DefaultResolver
+	}
+
+	return c.Resolver
+}
+
+// clock returns the current time.
+func (c *DeliverabilityChecker) clock() time.Time {
+	if c.now == nil {
+		return time.Now()
+	}
+
+	return c.now()
+}
+
+// cached returns the cached result for the domain, removing it if it has
+// expired. The caller must hold the lock.
+func (c *DeliverabilityChecker) cached(d string, now time.Time) (*DeliverabilityResult, bool) {
+	e, ok := c.cache[d]
+	if !ok {
+		return nil, false
+	}
+
+	if !now.Before(e.expires) {
+		delete(c.cache, d)
+		return nil, false
+	}
+
+	return e.result, true
+}
+
+// store caches the result for the domain, first removing every expired result
+// if the cache has grown large enough. The caller must hold the lock.
+func (c *DeliverabilityChecker) store(d string, e deliverabilityEntry, now time.Time) {
+	if c.cache == nil {
+		c.cache = map[string]deliverabilityEntry{}
+	}
+
+	if len(c.cache) >= c.sweepAt {
+		for k, ce := range c.cache {
+			if !now.Before(ce.expires) {
+				delete(c.cache, k)
+			}
+		}
+
+		c.sweepAt = 2 * len(c.cache)
+		if c.sweepAt < minDeliverabilitySweep {
+			c.sweepAt = minDeliverabilitySweep
+		}
+	}
+
+	c.cache[d] = e
+}
+
+// isNotFound returns true if the error reports that the name or records do not
+// exist.
+func isNotFound(err error) bool {
+	var de *net.DNSError
+	return errors.As(err, &de) && de.IsNotFound
+}
+
+// isNullMX returns true if the records are a null MX, i.e., a single record
+// with the host ".".
+func isNullMX(mxs []*net.MX) bool {
+	return len(mxs) == 1 && strings.TrimSuffix(mxs[0].Host, ".") == ""
+}
+
+// Check reports whether mail can be delivered to the domain of the address.
+// Domain literals and reserved top-level domains are reported without making
+// any lookup. Otherwise, the MX records of the domain are looked up, falling
+// back to its A and AAAA records when there are none.
+//
+// An error is returned only when a lookup fails with a temporary error, in
+// which case the status is DeliverabilityUnknown and nothing is cached. Each
+// call returns a new result, so the caller may modify it.
+func (c *DeliverabilityChecker) Check(ctx context.Context, as *AddrSpec) (*DeliverabilityResult, error) {
+	d := as.Domain()
+	if strings.HasPrefix(d, "[") {
+		return &DeliverabilityResult{Domain: d, Status: SkippedDomainLiteral}, nil
+	}
+
+	d = domainKey(d)
+	if isReservedTLD(d) {
+		return &DeliverabilityResult{Domain: d, Status: UndeliverableReservedTLD}, nil
+	}
+
+	c.lock.Lock()
+	cr, ok := c.cached(d, c.clock())
+	c.lock.Unlock()
+	if ok {
+		return cr.clone(), nil
+	}
+
+	r, err := c.lookup(ctx, d)
+	if err != nil {
+		return r, err
+	}
+
+	ttl := c.TTL
+	if r.Status == UndeliverableNoHost {
+		ttl = c.NegativeTTL
+	}
+
+	if ttl > 0 {
+		now := c.clock()
+		c.lock.Lock()
+		c.store(d, deliverabilityEntry{r.clone(), now.Add(ttl)}, now)
+		c.lock.Unlock()
+	}
+
+	return r, nil
+}
+
+// lookup makes the DNS lookups needed to check the domain.
+func (c *DeliverabilityChecker) lookup(ctx context.Context, d string) (*DeliverabilityResult, error) {
+	r := &DeliverabilityResult{Domain: d, Status: DeliverabilityUnknown}
+
+	mxs, err := c.resolver().LookupMX(ctx, d)
+	if err != nil && !isNotFound(err) {
+		return r, err
+	}
+
+	switch {
+	case isNullMX(mxs):
+		r.Status = UndeliverableNullMX
+		r.MX = mxs
+		return r, nil
+	case len(mxs) > 0:
+		r.Status = DeliverableMX
+		r.MX = mxs
+		return r, nil
+	}
+
+	addrs, err := c.resolver().LookupIPAddr(ctx, d)
+	if err != nil && !isNotFound(err) {
+		return r, err
+	}
+
+	if len(addrs) > 0 {
+		r.Status = DeliverableImplicitMX
+		r.Addrs = addrs
+	} else {
+		r.Status = UndeliverableNoHost
+	}
+
+	return r, nil
+}
+
+// Forget removes any cached result for the domain.
+func (c *DeliverabilityChecker) Forget(domain string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.cache, domainKey(domain))
+}
+
+// CheckDeliverability checks the domain of the address using
+// DefaultDeliverabilityChecker. See DeliverabilityChecker.Check.
+func CheckDeliverability(ctx context.Context, as *AddrSpec) (*DeliverabilityResult, error) {
+	return DefaultDeliverabilityChecker.Check(ctx, as)
+}
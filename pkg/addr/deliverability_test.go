@@ -0,0 +1,235 @@
+package addr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeResolver is an in-memory Resolver that counts its lookups.
+type fakeResolver struct {
+	lock    sync.Mutex
+	mx      map[string][]*net.MX
+	ip      map[string][]net.IPAddr
+	fail    map[string]error
+	lookups int
+}
+
+func (r *fakeResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.lookups++
+	if err := r.fail[name]; err != nil {
+		return nil, err
+	}
+	if mxs, ok := r.mx[name]; ok {
+		return mxs, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func (r *fakeResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.lookups++
+	if ips, ok := r.ip[host]; ok {
+		return ips, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func newFakeResolver() *fakeResolver {
+	return &fakeResolver{
+		mx: map[string][]*net.MX{
+			"example.com":               {{Host: "mx.example.com.", Pref: 10}},
+			"null.example.org":          {{Host: ".", Pref: 0}},
+			"xn--bcher-kva.example.net": {{Host: "mx.example.net.", Pref: 10}},
+		},
+		ip: map[string][]net.IPAddr{
+			"implicit.example.org": {{IP: net.ParseIP("192.0.2.1")}},
+		},
+		fail: map[string]error{
+			"broken.example.org": &net.DNSError{Err: "server misbehaving", Name: "broken.example.org", IsTemporary: true},
+		},
+	}
+}
+
+func TestCheckDeliverability(t *testing.T) {
+	t.Parallel()
+
+	c := NewDeliverabilityChecker(newFakeResolver())
+	ctx := context.Background()
+
+	tests := []struct {
+		domain string
+		status DeliverabilityStatus
+	}{
+		{"example.com", DeliverableMX},
+		{"EXAMPLE.com", DeliverableMX},
+		{"bücher.example.net", DeliverableMX},
+		{"implicit.example.org", DeliverableImplicitMX},
+		{"null.example.org", UndeliverableNullMX},
+		{"missing.example.org", UndeliverableNoHost},
+		{"mail.test", UndeliverableReservedTLD},
+		{"localhost", UndeliverableReservedTLD},
+		{"[192.0.2.1]", SkippedDomainLiteral},
+	}
+
+	for _, tc := range tests {
+		r, err := c.Check(ctx, NewAddrSpec("john", tc.domain))
+		assert.NoError(t, err, tc.domain)
+		assert.Equal(t, tc.status, r.Status, tc.domain)
+		assert.Equal(t, tc.status == DeliverableMX || tc.status == DeliverableImplicitMX, r.Deliverable(), tc.domain)
+	}
+
+	r, err := c.Check(ctx, NewAddrSpec("john", "broken.example.org"))
+	assert.Error(t, err)
+	assert.Equal(t, DeliverabilityUnknown, r.Status)
+	assert.False(t, r.Deliverable())
+}
+
+func TestDeliverabilityCache(t *testing.T) {
+	t.Parallel()
+
+	fr := newFakeResolver()
+	c := NewDeliverabilityChecker(fr)
+	ctx := context.Background()
+
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	as := NewAddrSpec("john", "example.com")
+	r, err := c.Check(ctx, as)
+	assert.NoError(t, err)
+	assert.Equal(t, []*net.MX{{Host: "mx.example.com.", Pref: 10}}, r.MX)
+	assert.Equal(t, 1, fr.lookups)
+
+	_, _ = c.Check(ctx, NewAddrSpec("jane", "Example.COM"))
+	assert.Equal(t, 1, fr.lookups)
+
+	now = now.Add(c.TTL)
+	_, _ = c.Check(ctx, as)
+	assert.Equal(t, 2, fr.lookups)
+
+	// negative results expire sooner
+	missing := NewAddrSpec("john", "missing.example.org")
+	_, _ = c.Check(ctx, missing)
+	assert.Equal(t, 4, fr.lookups)
+	now = now.Add(c.NegativeTTL - time.Second)
+	_, _ = c.Check(ctx, missing)
+	assert.Equal(t, 4, fr.lookups)
+	now = now.Add(time.Second)
+	_, _ = c.Check(ctx, missing)
+	assert.Equal(t, 6, fr.lookups)
+
+	// temporary errors are not cached
+	broken := NewAddrSpec("john", "broken.example.org")
+	_, err = c.Check(ctx, broken)
+	var de *net.DNSError
+	assert.True(t, errors.As(err, &de))
+	_, _ = c.Check(ctx, broken)
+	assert.Equal(t, 8, fr.lookups)
+
+	c.Forget("example.com")
+	_, _ = c.Check(ctx, as)
+	assert.Equal(t, 9, fr.lookups)
+}
+
+func TestDeliverabilityCheckerZero(t *testing.T) {
+	t.Parallel()
+
+	fr := newFakeResolver()
+	ctx := context.Background()
+	as := NewAddrSpec("john", "example.com")
+
+	c := &DeliverabilityChecker{Resolver: fr}
+	r, err := c.Check(ctx, as)
+	assert.NoError(t, err)
+	assert.Equal(t, DeliverableMX, r.Status)
+	_, _ = c.Check(ctx, as)
+	assert.Equal(t, 2, fr.lookups)
+
+	c = &DeliverabilityChecker{Resolver: fr, TTL: time.Hour}
+	_, _ = c.Check(ctx, as)
+	_, _ = c.Check(ctx, as)
+	assert.Equal(t, 3, fr.lookups)
+
+	// with no resolver, the default resolver is used; the context is
+	// canceled so that no lookup is actually made
+	var zc DeliverabilityChecker
+	assert.Equal(t, net.DefaultResolver, zc.resolver())
+
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	r, err = zc.Check(cctx, as)
+	assert.Error(t, err)
+	assert.Equal(t, DeliverabilityUnknown, r.Status)
+}
+
+func TestDeliverabilityResultCopied(t *testing.T) {
+	t.Parallel()
+
+	c := NewDeliverabilityChecker(newFakeResolver())
+	ctx := context.Background()
+	as := NewAddrSpec("john", "example.com")
+
+	r, err := c.Check(ctx, as)
+	assert.NoError(t, err)
+	r.Status = UndeliverableNoHost
+	r.MX[0].Host = "evil.example.net."
+
+	r, err = c.Check(ctx, as)
+	assert.NoError(t, err)
+	assert.Equal(t, DeliverableMX, r.Status)
+	assert.Equal(t, []*net.MX{{Host: "mx.example.com.", Pref: 10}}, r.MX)
+
+	r.MX[0].Host = "evil.example.net."
+	r, _ = c.Check(ctx, as)
+	assert.Equal(t, "mx.example.com.", r.MX[0].Host)
+}
+
+func TestDeliverabilityCacheExpires(t *testing.T) {
+	t.Parallel()
+
+	c := NewDeliverabilityChecker(newFakeResolver())
+	ctx := context.Background()
+
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	_, _ = c.Check(ctx, NewAddrSpec("john", "example.com"))
+	assert.Len(t, c.cache, 1)
+
+	// an expired result is removed when it is looked up
+	now = now.Add(c.TTL)
+	c.lock.Lock()
+	_, ok := c.cached("example.com", now)
+	c.lock.Unlock()
+	assert.False(t, ok)
+	assert.Len(t, c.cache, 0)
+
+	// expired results of other domains are removed as the cache grows
+	for i := 0; i < minDeliverabilitySweep; i++ {
+		_, _ = c.Check(ctx, NewAddrSpec("john", fmt.Sprintf("d%d.missing.example.org", i)))
+	}
+	assert.Len(t, c.cache, minDeliverabilitySweep)
+
+	now = now.Add(c.NegativeTTL)
+	_, _ = c.Check(ctx, NewAddrSpec("john", "example.com"))
+	assert.Len(t, c.cache, 1)
+}
+
+func TestDeliverabilityStatusString(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "null MX", UndeliverableNullMX.String())
+	assert.Equal(t, "unknown", DeliverabilityStatus(42).String())
+}
@@ -0,0 +1,482 @@
+package addr
+
+import (
+	"net"
+	"strings"
+
+	"github.com/zostay/go-addr/pkg/rd"
+	p "github.com/zostay/go-addr/pkg/rfc5322"
+)
+
+// DiagnosticCategory groups diagnostic codes by what they mean for the address.
+// The categories are ordered from least to most serious.
+type DiagnosticCategory int
+
+// These are the diagnostic categories.
+const (
+	// CategoryValid means the address is valid without reservation.
+	CategoryValid DiagnosticCategory = iota
+
+	// CategoryDNSWarning means the address is valid, but its domain is
+	// unlikely to be able to receive mail.
+	CategoryDNSWarning
+
+	// CategoryRFC5321 means the address is valid for SMTP, but uses a
+	// feature that is rarely supported or deprecated, such as a quoted local
+	// part or a domain literal.
+	CategoryRFC5321
+
+	// CategoryCFWS means the address contains comments or folding whitespace,
+	// which are valid in a header, but not in an SMTP envelope.
+	CategoryCFWS
+
+	// CategoryObsolete means the address uses obsolete syntax from RFC 5322
+	// section 4.
+	CategoryObsolete
+
+	// CategoryRFC5322 means the address is valid according to the syntax of
+	// RFC 5322, but cannot be used with SMTP, e.g., because it is too long.
+	CategoryRFC5322
+
+	// CategoryError means the address is not valid.
+	CategoryError
+)
+
+var diagnosticCategoryNames = []string{
+	"valid",
+	"DNS warning",
+	"RFC 5321",
+	"CFWS",
+	"obsolete",
+	"RFC 5322",
+	"error",
+}
+
+// String returns the name of the category.
+func (c DiagnosticCategory) String() string {
+	if c < 0 || int(c) >= len(diagnosticCategoryNames) {
+		return "unknown"
+	}
+
+	return diagnosticCategoryNames[c]
+}
+
+// Severity describes how serious a diagnostic is.
+type Severity int
+
+// These are the severities.
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+// String returns the name of the severity.
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// Severity returns the severity of diagnostics in the category.
+func (c DiagnosticCategory) Severity() Severity {
+	switch c {
+	case CategoryValid, CategoryCFWS:
+		return SeverityInfo
+	case CategoryError:
+		return SeverityError
+	default:
+		return SeverityWarning
+	}
+}
+
+// DiagnosticCode identifies a single kind of finding. The codes are stable and
+// may be stored or used to configure a policy.
+type DiagnosticCode string
+
+// These are the diagnostic codes.
+const (
+	CodeReservedTLD DiagnosticCode = "dnswarn.reserved_tld"
+
+	CodeQuotedString   DiagnosticCode = "rfc5321.quoted_string"
+	CodeAddressLiteral DiagnosticCode = "rfc5321.address_literal"
+	CodeTLD            DiagnosticCode = "rfc5321.tld"
+	CodeTLDNumeric     DiagnosticCode = "rfc5321.tld_numeric"
+
+	CodeComment DiagnosticCode = "cfws.comment"
+	CodeFWS     DiagnosticCode = "cfws.fws"
+
+	CodeObsolete DiagnosticCode = "obsolete.syntax"
+
+	CodeDomainLiteral  DiagnosticCode = "rfc5322.domain_literal"
+	CodeLocalTooLong   DiagnosticCode = "rfc5322.local_too_long"
+	CodeDomainTooLong  DiagnosticCode = "rfc5322.domain_too_long"
+	CodeLabelTooLong   DiagnosticCode = "rfc5322.label_too_long"
+	CodePathTooLong    DiagnosticCode = "rfc5322.too_long"
+	CodeInvalidDomain  DiagnosticCode = "rfc5322.domain"
+	CodeParse          DiagnosticCode = "err.parse"
+	CodeTrailingText   DiagnosticCode = "err.trailing_text"
+	CodeUnexpectedType DiagnosticCode = "err.unexpected_type"
+)
+
+var diagnosticCodeCategories = map[DiagnosticCode]DiagnosticCategory{
+	CodeReservedTLD:    CategoryDNSWarning,
+	CodeQuotedString:   CategoryRFC5321,
+	CodeAddressLiteral: CategoryRFC5321,
+	CodeTLD:            CategoryRFC5321,
+	CodeTLDNumeric:     CategoryRFC5321,
+	CodeComment:        CategoryCFWS,
+	CodeFWS:            CategoryCFWS,
+	CodeObsolete:       CategoryObsolete,
+	CodeDomainLiteral:  CategoryRFC5322,
+	CodeLocalTooLong:   CategoryRFC5322,
+	CodeDomainTooLong:  CategoryRFC5322,
+	CodeLabelTooLong:   CategoryRFC5322,
+	CodePathTooLong:    CategoryRFC5322,
+	CodeInvalidDomain:  CategoryRFC5322,
+	CodeParse:          CategoryError,
+	CodeTrailingText:   CategoryError,
+	CodeUnexpectedType: CategoryError,
+}
+
+// Category returns the category of the code.
+func (c DiagnosticCode) Category() DiagnosticCategory {
+	if cat, ok := diagnosticCodeCategories[c]; ok {
+		return cat
+	}
+
+	return CategoryError
+}
+
+// Diagnostic is a single finding about an address.
+type Diagnostic struct {
+	Code       DiagnosticCode // identifies the finding
+	Production string         // the obsolete production used, for CodeObsolete
+	Text       string         // the part of the address the finding is about
+}
+
+// Category returns the category of the diagnostic.
+func (d Diagnostic) Category() DiagnosticCategory { return d.Code.Category() }
+
+// Severity returns the severity of the diagnostic.
+func (d Diagnostic) Severity() Severity { return d.Code.Category().Severity() }
+
+// Diagnosis is the list of every finding about an address. An empty diagnosis
+// means the address is valid without reservation.
+type Diagnosis []Diagnostic
+
+// Category returns the most serious category of all the diagnostics or
+// CategoryValid if there are none.
+func (ds Diagnosis) Category() DiagnosticCategory {
+	c := CategoryValid
+	for _, d := range ds {
+		if d.Category() > c {
+			c = d.Category()
+		}
+	}
+	return c
+}
+
+// Severity returns the most serious severity of all the diagnostics.
+func (ds Diagnosis) Severity() Severity {
+	return ds.Category().Severity()
+}
+
+// Has returns true if any diagnostic has the given code.
+func (ds Diagnosis) Has(code DiagnosticCode) bool {
+	for _, d := range ds {
+		if d.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Productions returns the names of the obsolete productions used, e.g.,
+// "obs-local-part", in the order they were found.
+func (ds Diagnosis) Productions() []string {
+	var ps []string
+	for _, d := range ds {
+		if d.Code == CodeObsolete {
+			ps = append(ps, d.Production)
+		}
+	}
+	return ps
+}
+
+// reservedTLDs are the top-level domains reserved by RFC 2606 and RFC 6761,
+// which will never be delegated and so cannot receive mail.
+var reservedTLDs = map[string]struct{}{
+	"example":   {},
+	"invalid":   {},
+	"localhost": {},
+	"test":      {},
+}
+
+// isReservedTLD returns true if the last label of the domain is reserved.
+func isReservedTLD(d string) bool {
+	d = strings.TrimSuffix(strings.ToLower(d), ".")
+	_, ok := reservedTLDs[d[strings.LastIndex(d, ".")+1:]]
+	return ok
+}
+
+// isAddressLiteral returns true if the content of the domain literal is an
+// address literal usable with SMTP, i.e., an IPv4 address or "IPv6:" followed
+// by an IPv6 address (see RFC 5321 section 4.1.3).
+func isAddressLiteral(lit string) bool {
+	if len(lit) > 5 && strings.EqualFold(lit[:5], "IPv6:") {
+		ip := net.ParseIP(lit[5:])
+		return ip != nil && strings.Contains(lit[5:], ":")
+	}
+
+	ip := net.ParseIP(lit)
+	return ip != nil && ip.To4() != nil && !strings.Contains(lit, ":")
+}
+
+// These flags describe where within an address a match was found.
+const (
+	inAddrSpec = 1 << iota
+	inComment
+	inQuotedString
+	inDomainLiteral
+)
+
+// diagnoser collects the diagnostics found while walking a match tree.
+type diagnoser struct {
+	ds   Diagnosis
+	seen map[Diagnostic]struct{}
+
+	// text and space track whether text and then whitespace have been seen
+	// within the current addr-spec, so that whitespace before or after the
+	// addr-spec is not reported as folding whitespace.
+	text, space bool
+}
+
+// add records the diagnostic unless the same one was already recorded.
+func (dg *diagnoser) add(code DiagnosticCode, production, text string) {
+	d := Diagnostic{code, production, text}
+	if dg.seen == nil {
+		dg.seen = map[Diagnostic]struct{}{}
+	}
+
+	if _, ok := dg.seen[d]; ok {
+		return
+	}
+
+	dg.seen[d] = struct{}{}
+	dg.ds = append(dg.ds, d)
+}
+
+// obsolete records the use of an obsolete production.
+func (dg *diagnoser) obsolete(production string, m *rd.Match) {
+	dg.add(CodeObsolete, production, strings.TrimSpace(string(m.Content)))
+}
+
+// isObsNoWSCtl returns true if the byte is a control character only permitted
+// by the obsolete syntax.
+func isObsNoWSCtl(c byte) bool {
+	return c == 0 || (c >= 0x1 && c <= 0x8) || c == 0xb || c == 0xc || (c >= 0xe && c <= 0x1f) || c == 0x7f
+}
+
+// walk records the diagnostics for the match and everything within it.
+func (dg *diagnoser) walk(m *rd.Match, where int) {
+	if m == nil {
+		return
+	}
+
+	switch m.Tag {
+	case p.TComment:
+		if where&inComment == 0 {
+			dg.add(CodeComment, "", string(m.Group["comment-content"].Content))
+		}
+		where |= inComment
+	case p.TQuotedString:
+		where |= inQuotedString
+	case p.TObsQP:
+		dg.obsolete("obs-qp", m)
+		return
+	case p.TObsAngleAddr:
+		dg.obsolete("obs-angle-addr", m)
+	case p.TObsRoute:
+		dg.obsolete("obs-route", m)
+	case p.TObsMboxList:
+		dg.obsolete("obs-mbox-list", m)
+	case p.TObsAddrList:
+		dg.obsolete("obs-addr-list", m)
+	case p.TObsGroupList:
+		dg.obsolete("obs-group-list", m)
+	case p.TObsLocalPart:
+		dg.obsolete("obs-local-part", m)
+	case p.TObsDomain:
+		dg.obsolete("obs-domain", m)
+	case p.TDisplayName:
+		if gp := m.Group["phrase"]; gp != nil && gp.Tag != p.TWords {
+			dg.obsolete("obs-phrase", gp)
+		}
+	case p.TAddrSpec:
+		dg.addrSpec(m)
+		dg.text, dg.space = false, false
+		where |= inAddrSpec
+	}
+
+	if gc := m.Group["crlfs"]; gc != nil && len(gc.Submatch) > 1 {
+		dg.obsolete("obs-FWS", m)
+	}
+
+	if len(m.Submatch) == 0 && len(m.Content) == 1 {
+		dg.leaf(m, where)
+	}
+
+	if gl := m.Group["literal"]; gl != nil && where&inAddrSpec != 0 {
+		dg.walk(m.Group["pre-literal"], where)
+		dg.walk(gl, where|inDomainLiteral)
+		dg.walk(m.Group["post-literal"], where)
+		return
+	}
+
+	for _, sm := range m.Submatch {
+		dg.walk(sm, where)
+	}
+}
+
+// leaf records the diagnostics for a single character.
+func (dg *diagnoser) leaf(m *rd.Match, where int) {
+	c := m.Content[0]
+	isSpace := c == ' ' || c == '\t' || c == '\r' || c == '\n'
+	if where&inAddrSpec != 0 && where&inComment == 0 {
+		switch {
+		case !isSpace || where&inQuotedString != 0:
+			if dg.space {
+				dg.add(CodeFWS, "", "")
+			}
+			dg.text, dg.space = true, false
+		case dg.text:
+			dg.space = true
+		}
+	}
+
+	switch {
+	case isObsNoWSCtl(c) && where&inComment != 0:
+		dg.obsolete("obs-ctext", m)
+	case isObsNoWSCtl(c) && where&inQuotedString != 0:
+		dg.obsolete("obs-qtext", m)
+	case (isObsNoWSCtl(c) || c == '\\') && where&inDomainLiteral != 0:
+		dg.obsolete("obs-dtext", m)
+	}
+}
+
+// addrSpec records the diagnostics that apply to the addr-spec as a whole.
+func (dg *diagnoser) addrSpec(m *rd.Match) {
+	if glp := m.Group["local-part"]; glp != nil && glp.Tag == p.TQuotedString {
+		dg.add(CodeQuotedString, "", strings.TrimSpace(string(glp.Content)))
+	}
+
+	as, ok := m.Made.(*AddrSpec)
+	if !ok {
+		return
+	}
+
+	if gd := m.Group["domain"]; gd != nil && gd.Group["literal"] != nil {
+		lit := strings.TrimSpace(string(gd.Group["literal"].Content))
+		if isAddressLiteral(lit) {
+			dg.add(CodeAddressLiteral, "", as.domain)
+		} else {
+			dg.add(CodeDomainLiteral, "", as.domain)
+		}
+	} else {
+		labels := strings.Split(as.domain, ".")
+		tld := labels[len(labels)-1]
+		if len(labels) == 1 {
+			dg.add(CodeTLD, "", as.domain)
+		}
+		if strings.Trim(tld, "0123456789") == "" {
+			dg.add(CodeTLDNumeric, "", tld)
+		}
+		if isReservedTLD(as.domain) {
+			dg.add(CodeReservedTLD, "", tld)
+		}
+	}
+
+	for _, v := range as.violations() {
+		switch v.Kind {
+		case ViolationLocalPartLength:
+			dg.add(CodeLocalTooLong, "", v.Part)
+		case ViolationDomainLength:
+			dg.add(CodeDomainTooLong, "", v.Part)
+		case ViolationLabelLength:
+			dg.add(CodeLabelTooLong, "", v.Part)
+		case ViolationPathLength:
+			dg.add(CodePathTooLong, "", v.Part)
+		case ViolationLabelSyntax, ViolationIDNA:
+			dg.add(CodeInvalidDomain, "", v.Part)
+		}
+	}
+}
+
+// DiagnoseMatch returns the diagnosis of the match tree produced by one of the
+// rfc5322 matchers for addresses. ApplyActions must already have been called on
+// the match. Nothing is parsed again; all of the findings come from the tree.
+func DiagnoseMatch(m *rd.Match) Diagnosis {
+	var dg diagnoser
+	dg.walk(m, 0)
+	return dg.ds
+}
+
+// diagnose returns the diagnosis of the match and the unparsed remainder as
+// returned by one of the rfc5322 matchers.
+func diagnose(m *rd.Match, cs []byte, err error) Diagnosis {
+	if err != nil {
+		if err == ErrParse {
+			return Diagnosis{{Code: CodeParse, Text: string(cs)}}
+		}
+		return Diagnosis{{Code: CodeUnexpectedType, Text: err.Error()}}
+	}
+
+	ds := DiagnoseMatch(m)
+	if len(cs) > 0 {
+		ds = append(ds, Diagnostic{Code: CodeTrailingText, Text: string(cs)})
+	}
+
+	return ds
+}
+
+// DiagnoseEmailAddrSpec parses the string as a bare email address like
+// ParseEmailAddrSpec and returns the address along with a diagnosis of every
+// finding about it. If the string does not parse at all, the address is nil
+// and the diagnosis contains a CodeParse error. If there is text remaining
+// after the address, the diagnosis contains a CodeTrailingText error.
+func DiagnoseEmailAddrSpec(a string) (*AddrSpec, Diagnosis) {
+	a = strings.TrimSpace(a)
+	m, cs := p.MatchAddrSpec([]byte(a))
+
+	var address *AddrSpec
+	err := ApplyActions(m, &address)
+	if err != nil {
+		return nil, diagnose(m, []byte(a), err)
+	}
+
+	return address, diagnose(m, cs, nil)
+}
+
+// DiagnoseEmailMailbox parses the string as a mailbox like ParseEmailMailbox and
+// returns the mailbox along with a diagnosis of every finding about it. Errors
+// are reported as they are by DiagnoseEmailAddrSpec.
+func DiagnoseEmailMailbox(a string) (*Mailbox, Diagnosis) {
+	a = strings.TrimSpace(a)
+	m, cs := p.MatchMailbox([]byte(a))
+
+	var mailbox *Mailbox
+	err := ApplyActions(m, &mailbox)
+	if err != nil {
+		return nil, diagnose(m, []byte(a), err)
+	}
+
+	return mailbox, diagnose(m, cs, nil)
+}
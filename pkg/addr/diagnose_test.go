@@ -0,0 +1,101 @@
+package addr
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	p "github.com/zostay/go-addr/pkg/rfc5322"
+)
+
+func TestDiagnoseEmailAddrSpec(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in       string
+		codes    []DiagnosticCode
+		category DiagnosticCategory
+	}{
+		{"john@example.com", []DiagnosticCode{}, CategoryValid},
+		{`"john doe"@example.com`, []DiagnosticCode{CodeQuotedString}, CategoryRFC5321},
+		{"john(comment)@example.com", []DiagnosticCode{CodeComment}, CategoryCFWS},
+		{"john . smith@example.com", []DiagnosticCode{CodeObsolete, CodeFWS}, CategoryObsolete},
+		{"john@[192.0.2.1]", []DiagnosticCode{CodeAddressLiteral}, CategoryRFC5321},
+		{"john@[IPv6:2001:db8::1]", []DiagnosticCode{CodeAddressLiteral}, CategoryRFC5321},
+		{"john@[mail.example]", []DiagnosticCode{CodeDomainLiteral}, CategoryRFC5322},
+		{"john@localhost", []DiagnosticCode{CodeTLD, CodeReservedTLD}, CategoryRFC5321},
+		{"john@mail.test", []DiagnosticCode{CodeReservedTLD}, CategoryDNSWarning},
+		{"john@192.0.2.1", []DiagnosticCode{CodeTLDNumeric}, CategoryRFC5321},
+		{strings.Repeat("a", 65) + "@example.com", []DiagnosticCode{CodeLocalTooLong}, CategoryRFC5322},
+		{"john@-bad.example.com", []DiagnosticCode{CodeInvalidDomain}, CategoryRFC5322},
+	}
+
+	for _, tc := range tests {
+		as, ds := DiagnoseEmailAddrSpec(tc.in)
+		assert.NotNil(t, as, tc.in)
+		assert.Equal(t, tc.codes, diagnosticCodes(ds), tc.in)
+		assert.Equal(t, tc.category, ds.Category(), tc.in)
+	}
+}
+
+func TestDiagnoseErrors(t *testing.T) {
+	t.Parallel()
+
+	as, ds := DiagnoseEmailAddrSpec("@@")
+	assert.Nil(t, as)
+	assert.Equal(t, Diagnosis{{Code: CodeParse, Text: "@@"}}, ds)
+	assert.Equal(t, SeverityError, ds.Severity())
+
+	as, ds = DiagnoseEmailAddrSpec("john@example.com, jane@example.com")
+	assert.Equal(t, "john@example.com", as.CleanString())
+	assert.True(t, ds.Has(CodeTrailingText))
+	assert.Equal(t, CategoryError, ds.Category())
+}
+
+func TestDiagnoseEmailMailbox(t *testing.T) {
+	t.Parallel()
+
+	mb, ds := DiagnoseEmailMailbox("John Q. Public <jq@example.com>")
+	assert.Equal(t, "jq@example.com", mb.AddrSpec().CleanString())
+	assert.Equal(t, []string{"obs-phrase"}, ds.Productions())
+	assert.Equal(t, SeverityWarning, ds.Severity())
+
+	_, ds = DiagnoseEmailMailbox("<@route.example:jq@example.com>")
+	assert.Equal(t, []string{"obs-angle-addr", "obs-route"}, ds.Productions())
+
+	_, ds = DiagnoseEmailMailbox("Joe (x) <jq@example.com> (y)")
+	assert.Equal(t, Diagnosis{
+		{Code: CodeComment, Text: "x"},
+		{Code: CodeComment, Text: "y"},
+	}, ds)
+	assert.Equal(t, SeverityInfo, ds.Severity())
+
+	_, ds = DiagnoseEmailMailbox("Joe <jq@example.com>")
+	assert.Empty(t, ds)
+	assert.Equal(t, CategoryValid, ds.Category())
+}
+
+func TestDiagnoseMatch(t *testing.T) {
+	t.Parallel()
+
+	m, cs := p.MatchAddressList([]byte("a@example.com, (note) b@example.com, Team: c@x.test;"))
+	assert.Empty(t, cs)
+
+	var al AddressList
+	assert.NoError(t, ApplyActions(m, &al))
+
+	ds := DiagnoseMatch(m)
+	assert.Equal(t, []DiagnosticCode{CodeComment, CodeReservedTLD}, diagnosticCodes(ds))
+	assert.Equal(t, CategoryCFWS, ds.Category())
+}
+
+func TestDiagnosticStrings(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "obsolete", CategoryObsolete.String())
+	assert.Equal(t, "unknown", DiagnosticCategory(99).String())
+	assert.Equal(t, "warning", SeverityWarning.String())
+	assert.Equal(t, CategoryCFWS, CodeFWS.Category())
+	assert.Equal(t, CategoryError, DiagnosticCode("nope").Category())
+}
@@ -0,0 +1,154 @@
+package addr
+
+import (
+	"errors"
+	"strings"
+)
+
+// AlignmentMode is a DMARC identifier alignment mode, as described in RFC 7489
+// section 3.1.
+type AlignmentMode int
+
+// These are the alignment modes.
+const (
+	// AlignRelaxed requires the authenticated domain and the author domain to
+	// share an organizational domain. This is the default of DMARC, named "r"
+	// in the aspf and adkim tags of a policy record.
+	AlignRelaxed AlignmentMode = iota
+
+	// AlignStrict requires the authenticated domain and the author domain to
+	// be identical, named "s" in a policy record.
+	AlignStrict
+)
+
+// String returns the name of the mode.
+func (m AlignmentMode) String() string {
+	switch m {
+	case AlignRelaxed:
+		return "relaxed"
+	case AlignStrict:
+		return "strict"
+	default:
+		return "unknown"
+	}
+}
+
+var (
+	// ErrNoAuthor is returned by CheckAlignment when the From mailbox list is
+	// empty, so there is no author domain to align with.
+	ErrNoAuthor = errors.New("no author mailbox in From")
+
+	// ErrNoAuthDomain is returned by CheckAlignment when the authenticated
+	// domain is empty.
+	ErrNoAuthDomain = errors.New("no authenticated domain")
+)
+
+// AuthorAlignment is the alignment of a single author domain of a From field.
+type AuthorAlignment struct {
+	Domain    string // the author domain, in lowercase ASCII form
+	OrgDomain string // the organizational domain of the author domain
+	Aligned   bool   // true if the domain aligns with the authenticated domain
+}
+
+// AlignmentResult is the result of comparing the author domains of a From
+// field with a domain authenticated by SPF or DKIM.
+type AlignmentResult struct {
+	Mode          AlignmentMode     // the mode used to compare domains
+	AuthDomain    string            // the authenticated domain, in lowercase ASCII form
+	AuthOrgDomain string            // the organizational domain of AuthDomain
+	Authors       []AuthorAlignment // each distinct author domain, in order
+
+	// MultipleAuthors is true if the From field names more than one distinct
+	// author domain. RFC 7489 section 6.6.1 permits receivers to reject such
+	// messages outright. Those that do not must apply DMARC to every author
+	// domain, so Aligned is only true if every one of them aligns.
+	MultipleAuthors bool
+
+	// Aligned is true if every author domain aligns with AuthDomain.
+	Aligned bool
+}
+
+// alignmentDomain returns the domain in the form compared for alignment:
+// lowercase ASCII without a trailing dot.
+func alignmentDomain(d string) string {
+	return strings.TrimSuffix(domainKey(d), ".")
+}
+
+// OrganizationalDomain returns the organizational domain of the domain, as
+// described in RFC 7489 section 3.2, using the list set by
+// SetDefaultPublicSuffixList. This is the registrable domain or, if the domain
+// is itself a public suffix or a domain literal, the domain itself. The result
+// is in lowercase ASCII form.
+func OrganizationalDomain(d string) string {
+	return organizationDomain(alignmentDomain(d))
+}
+
+// aligned returns true if the author domain aligns with the authenticated
+// domain using the given mode.
+func (m AlignmentMode) aligned(author, authorOrg, auth, authOrg string) bool {
+	if strings.HasPrefix(author, "[") {
+		// a domain literal has no DNS to publish a policy under
+		return false
+	}
+
+	if m == AlignStrict {
+		return author == auth
+	}
+
+	return authorOrg == authOrg
+}
+
+// CheckAlignment compares the author domains of the From field with a domain
+// authenticated by SPF (the RFC5321.MailFrom domain) or DKIM (the d= tag of a
+// valid signature) using the given mode, as described in RFC 7489 section 3.1.
+// Domains are compared in lowercase ASCII form, so internationalized domains
+// align with their punycode equivalents.
+//
+// Mailboxes sharing an author domain are reported once. When the From field
+// names more than one author domain, MultipleAuthors is set and the result is
+// only aligned if every author domain aligns.
+//
+// It returns ErrNoAuthor if the From field has no mailboxes and
+// ErrNoAuthDomain if the authenticated domain is empty.
+func CheckAlignment(from MailboxList, authDomain string, mode AlignmentMode) (*AlignmentResult, error) {
+	if len(from) == 0 {
+		return nil, ErrNoAuthor
+	}
+
+	auth := alignmentDomain(authDomain)
+	if auth == "" {
+		return nil, ErrNoAuthDomain
+	}
+
+	res := &AlignmentResult{
+		Mode:          mode,
+		AuthDomain:    auth,
+		AuthOrgDomain: organizationDomain(auth),
+		Aligned:       true,
+	}
+
+	seen := map[string]bool{}
+	for _, mb := range from {
+		d := alignmentDomain(mb.Domain())
+		if seen[d] {
+			continue
+		}
+		seen[d] = true
+
+		org := organizationDomain(d)
+		ok := mode.aligned(d, org, auth, res.AuthOrgDomain)
+		res.Authors = append(res.Authors, AuthorAlignment{
+			Domain:    d,
+			OrgDomain: org,
+			Aligned:   ok,
+		})
+
+		if !ok {
+			res.Aligned = false
+		}
+	}
+
+	res.MultipleAuthors = len(res.Authors) > 1
+
+	return res, nil
+}
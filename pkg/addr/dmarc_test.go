@@ -0,0 +1,82 @@
+package addr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrganizationalDomain(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "example.com", OrganizationalDomain("mail.Example.COM."))
+	assert.Equal(t, "example.co.uk", OrganizationalDomain("a.b.example.co.uk"))
+	assert.Equal(t, "co.uk", OrganizationalDomain("co.uk"))
+	assert.Equal(t, "xn--bcher-kva.example", OrganizationalDomain("mail.bücher.example"))
+}
+
+func TestCheckAlignment(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		from    string
+		auth    string
+		mode    AlignmentMode
+		aligned bool
+	}{
+		{"john@example.com", "example.com", AlignStrict, true},
+		{"john@example.com", "EXAMPLE.com.", AlignStrict, true},
+		{"john@mail.example.com", "example.com", AlignStrict, false},
+		{"john@mail.example.com", "example.com", AlignRelaxed, true},
+		{"john@example.com", "bounces.example.com", AlignRelaxed, true},
+		{"john@example.co.uk", "other.co.uk", AlignRelaxed, false},
+		{"john@example.com", "example.net", AlignRelaxed, false},
+		{"john@[192.0.2.1]", "[192.0.2.1]", AlignStrict, false},
+	}
+
+	for _, tc := range tests {
+		res, err := CheckAlignment(mustParseList(t, tc.from).Flatten(), tc.auth, tc.mode)
+		if assert.NoError(t, err, tc.from) {
+			assert.Equal(t, tc.aligned, res.Aligned, "%s vs %s (%s)", tc.from, tc.auth, tc.mode)
+			assert.False(t, res.MultipleAuthors, tc.from)
+		}
+	}
+}
+
+func TestCheckAlignmentMultipleAuthors(t *testing.T) {
+	t.Parallel()
+
+	// the same domain twice is a single author domain
+	res, err := CheckAlignment(mustParseList(t, "a@example.com, b@Example.com").Flatten(), "example.com", AlignStrict)
+	if assert.NoError(t, err) {
+		assert.False(t, res.MultipleAuthors)
+		assert.True(t, res.Aligned)
+		assert.Len(t, res.Authors, 1)
+	}
+
+	res, err = CheckAlignment(mustParseList(t, "a@example.com, b@evil.example").Flatten(), "example.com", AlignRelaxed)
+	if assert.NoError(t, err) {
+		assert.True(t, res.MultipleAuthors)
+		assert.False(t, res.Aligned)
+		assert.Equal(t, []AuthorAlignment{
+			{Domain: "example.com", OrgDomain: "example.com", Aligned: true},
+			{Domain: "evil.example", OrgDomain: "evil.example", Aligned: false},
+		}, res.Authors)
+	}
+
+	res, err = CheckAlignment(mustParseList(t, "a@news.example.com, b@example.com").Flatten(), "example.com", AlignRelaxed)
+	if assert.NoError(t, err) {
+		assert.True(t, res.MultipleAuthors)
+		assert.True(t, res.Aligned)
+	}
+}
+
+func TestCheckAlignmentErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := CheckAlignment(nil, "example.com", AlignRelaxed)
+	assert.Equal(t, ErrNoAuthor, err)
+
+	_, err = CheckAlignment(mustParseList(t, "john@example.com").Flatten(), "", AlignRelaxed)
+	assert.Equal(t, ErrNoAuthDomain, err)
+}
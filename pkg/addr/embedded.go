@@ -0,0 +1,109 @@
+package addr
+
+import (
+	"strings"
+	"unicode"
+)
+
+// PartComment is the part of a mailbox in which a finding is made when it is
+// made in a comment.
+const PartComment = "comment"
+
+// EmbeddedAddress is an email address found within the display name or a
+// comment of a mailbox.
+type EmbeddedAddress struct {
+	Part     string    // where the address was found, e.g., PartDisplayName
+	Text     string    // the address as it was found
+	AddrSpec *AddrSpec // the address as parsed
+	Matches  bool      // true if the address is the same as that of the mailbox
+}
+
+// isEmbeddedLocalRune returns true if the rune may appear in the local part of
+// an address embedded in text.
+func isEmbeddedLocalRune(r rune) bool {
+	return r == '.' || r > unicode.MaxASCII && unicode.IsLetter(r) ||
+		r < unicode.MaxASCII && (r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+			strings.ContainsRune("!#$%&'*+-/=?^_`{|}~", r))
+}
+
+// isEmbeddedDomainRune returns true if the rune may appear in the domain of an
+// address embedded in text.
+func isEmbeddedDomainRune(r rune) bool {
+	return r == '.' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// findAddresses returns every string in the text that parses as an addr-spec
+// with a local part and a domain of at least two labels.
+func findAddresses(text string) []string {
+	var found []string
+	for i := 0; i < len(text); i++ {
+		if text[i] != '@' {
+			continue
+		}
+
+		start := strings.LastIndexFunc(text[:i], func(r rune) bool { return !isEmbeddedLocalRune(r) }) + 1
+		end := strings.IndexFunc(text[i+1:], func(r rune) bool { return !isEmbeddedDomainRune(r) })
+		if end < 0 {
+			end = len(text)
+		} else {
+			end += i + 1
+		}
+
+		lp := strings.Trim(text[start:i], ".")
+		d := strings.Trim(text[i+1:end], ".-")
+		if lp == "" || !strings.Contains(d, ".") {
+			continue
+		}
+
+		if _, err := ParseEmailAddrSpec(lp + "@" + d); err == nil {
+			found = append(found, lp+"@"+d)
+		} else if as := NewAddrSpec(lp, d); as.Validate() == nil {
+			// non-ASCII addresses cannot be parsed, but may be constructed
+			found = append(found, lp+"@"+d)
+		}
+
+		i = end - 1
+	}
+
+	return found
+}
+
+// EmbeddedAddresses returns every email address found within the display name
+// and the comments of the mailbox, as decoded from any MIME words. Each is
+// compared to the address of the mailbox after both are put into canonical
+// form (see EqualCanonical). An address in the display name that does not
+// match, as in "\"ceo@example.com\" <attacker@example.net>", is a common sign
+// of phishing.
+func (m *Mailbox) EmbeddedAddresses() []EmbeddedAddress {
+	var es []EmbeddedAddress
+	add := func(part, text string) {
+		for _, a := range findAddresses(text) {
+			i := strings.LastIndex(a, "@")
+			as := NewAddrSpec(a[:i], a[i+1:])
+			es = append(es, EmbeddedAddress{
+				Part:     part,
+				Text:     a,
+				AddrSpec: as,
+				Matches:  as.Equal(m.address, EqualCanonical),
+			})
+		}
+	}
+
+	add(PartDisplayName, m.displayName)
+	for _, c := range m.Comments() {
+		add(PartComment, c.Text)
+	}
+
+	return es
+}
+
+// HasMismatchedEmbeddedAddress returns true if any address found by
+// EmbeddedAddresses differs from the address of the mailbox.
+func (m *Mailbox) HasMismatchedEmbeddedAddress() bool {
+	for _, e := range m.EmbeddedAddresses() {
+		if !e.Matches {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package addr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindAddresses(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in  string
+		out []string
+	}{
+		{"ceo@ourcorp.com", []string{"ceo@ourcorp.com"}},
+		{"CEO (ceo@ourcorp.com).", []string{"ceo@ourcorp.com"}},
+		{"a@b.example and c.d@e.example", []string{"a@b.example", "c.d@e.example"}},
+		{"Jane Doe", nil},
+		{"@handle", nil},
+		{"user@localhost", nil},
+		{"Team @ Example.com", nil},
+		{"jörg@bücher.example", []string{"jörg@bücher.example"}},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.out, findAddresses(tc.in), tc.in)
+	}
+}
+
+func TestEmbeddedAddresses(t *testing.T) {
+	t.Parallel()
+
+	mb, err := ParseEmailMailbox(`"ceo@ourcorp.com" <attacker@evil.example>`)
+	if assert.NoError(t, err) {
+		es := mb.EmbeddedAddresses()
+		if assert.Len(t, es, 1) {
+			assert.Equal(t, PartDisplayName, es[0].Part)
+			assert.Equal(t, "ceo@ourcorp.com", es[0].Text)
+			assert.False(t, es[0].Matches)
+		}
+		assert.True(t, mb.HasMismatchedEmbeddedAddress())
+	}
+
+	mb, err = ParseEmailMailbox("CEO (ceo@ourcorp.com) <x@y.example>")
+	if assert.NoError(t, err) {
+		es := mb.EmbeddedAddresses()
+		if assert.Len(t, es, 1) {
+			assert.Equal(t, PartComment, es[0].Part)
+			assert.False(t, es[0].Matches)
+		}
+	}
+
+	mb, err = ParseEmailMailbox("=?UTF-8?Q?ceo=40ourcorp.com?= <attacker@evil.example>")
+	if assert.NoError(t, err) {
+		assert.True(t, mb.HasMismatchedEmbeddedAddress())
+	}
+
+	// the same address after canonicalization is not a mismatch
+	mb, err = ParseEmailMailbox(`"John@Example.COM" <John@example.com>`)
+	if assert.NoError(t, err) {
+		es := mb.EmbeddedAddresses()
+		if assert.Len(t, es, 1) {
+			assert.True(t, es[0].Matches)
+		}
+		assert.False(t, mb.HasMismatchedEmbeddedAddress())
+	}
+
+	mb, err = ParseEmailMailbox("John Smith <john@example.com>")
+	if assert.NoError(t, err) {
+		assert.Empty(t, mb.EmbeddedAddresses())
+	}
+}
+
+func TestCheckSpoofingEmbeddedAddress(t *testing.T) {
+	t.Parallel()
+
+	mb, err := ParseEmailMailbox(`"ceo@ourcorp.com" <attacker@evil.example>`)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []SpoofFinding{{
+			Kind:  SpoofEmbeddedAddress,
+			Part:  PartDisplayName,
+			Text:  "ceo@ourcorp.com",
+			Level: ASCIIOnly,
+		}}, CheckSpoofing(mb))
+	}
+}
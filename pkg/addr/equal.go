@@ -0,0 +1,232 @@
+package addr
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/zostay/go-addr/pkg/format"
+	"golang.org/x/text/unicode/norm"
+)
+
+// EqualMode selects how addresses are compared by the Equal and KeyFor
+// methods.
+type EqualMode int
+
+// These are the available comparison modes, from strictest to loosest, except
+// for EqualFull, which adds the display name and comment to EqualCanonical.
+const (
+	// EqualExact compares the local part and domain exactly as stored.
+	EqualExact EqualMode = iota
+
+	// EqualDomainFold compares the local part exactly, but ignores the case of
+	// the domain.
+	EqualDomainFold
+
+	// EqualCanonical compares the canonical forms of the addresses (see
+	// AddrSpec.Canonicalize). This is the mode used by Key.
+	EqualCanonical
+
+	// EqualFull compares the canonical forms of the addresses and also the
+	// display names and comments after normalizing them to NFC and collapsing
+	// whitespace.
+	EqualFull
+)
+
+// canonicalParts returns the canonical local part and domain of the address.
+// Unlike Canonicalize, this never fails, so that every address has a canonical
+// form (see domainKey).
+func canonicalParts(as *AddrSpec) (string, string) {
+	return norm.NFC.String(as.localPart), domainKey(as.domain)
+}
+
+// domainKey returns the canonical form of the domain. If the domain cannot be
+// converted to IDNA, it is only normalized to NFC and lowercased.
+func domainKey(d string) string {
+	c, err := canonicalDomain(d)
+	if err != nil {
+		return strings.ToLower(norm.NFC.String(d))
+	}
+
+	return c
+}
+
+// KeyFor returns a string that is identical for any two addresses that are
+// equal under the given mode and different otherwise.
+func (as *AddrSpec) KeyFor(mode EqualMode) string {
+	switch mode {
+	case EqualExact:
+		return format.RenderAddrSpec(as.localPart, as.domain)
+	case EqualDomainFold:
+		return format.RenderAddrSpec(as.localPart, strings.ToLower(as.domain))
+	default:
+		return format.RenderAddrSpec(canonicalParts(as))
+	}
+}
+
+// Key returns the canonical form of the address as a string, which is suitable
+// for use as a map key. This is the same as KeyFor(EqualCanonical).
+func (as *AddrSpec) Key() string { return as.KeyFor(EqualCanonical) }
+
+// Equal returns true if the two addresses are equal under the given mode. An
+// AddrSpec has no display name or comment, so EqualFull is the same as
+// EqualCanonical.
+func (as *AddrSpec) Equal(other *AddrSpec, mode EqualMode) bool {
+	return as.KeyFor(mode) == other.KeyFor(mode)
+}
+
+// Compare returns -1, 0, or 1 as the address sorts before, the same as, or
+// after the other address. Addresses are ordered by canonical domain, then by
+// canonical local part, and then by their exact form, so this is a total order
+// in which addresses that are equal under EqualCanonical are adjacent.
+func (as *AddrSpec) Compare(other *AddrSpec) int {
+	alp, ad := canonicalParts(as)
+	blp, bd := canonicalParts(other)
+
+	if c := strings.Compare(ad, bd); c != 0 {
+		return c
+	}
+
+	if c := strings.Compare(alp, blp); c != 0 {
+		return c
+	}
+
+	return strings.Compare(as.KeyFor(EqualExact), other.KeyFor(EqualExact))
+}
+
+// KeyFor returns a string that is identical for any two mailboxes that are
+// equal under the given mode and different otherwise. Except for EqualFull,
+// only the AddrSpec is considered.
+func (m *Mailbox) KeyFor(mode EqualMode) string {
+	k := m.address.KeyFor(mode)
+	if mode != EqualFull {
+		return k
+	}
+
+	return canonicalText(m.displayName) + "\x00" + k + "\x00" + canonicalText(m.Comment())
+}
+
+// Key returns the canonical form of the address of the mailbox as a string,
+// which is suitable for use as a map key. The display name and comment are
+// ignored. This is the same as KeyFor(EqualCanonical).
+func (m *Mailbox) Key() string { return m.KeyFor(EqualCanonical) }
+
+// Equal returns true if the two mailboxes are equal under the given mode.
+func (m *Mailbox) Equal(other *Mailbox, mode EqualMode) bool {
+	return m.KeyFor(mode) == other.KeyFor(mode)
+}
+
+// Compare returns -1, 0, or 1 as the mailbox sorts before, the same as, or
+// after the other mailbox. Mailboxes are ordered as by AddrSpec.Compare, then
+// by display name, and then by comment.
+func (m *Mailbox) Compare(other *Mailbox) int {
+	if c := m.address.Compare(other.address); c != 0 {
+		return c
+	}
+
+	if c := strings.Compare(m.displayName, other.displayName); c != 0 {
+		return c
+	}
+
+	return strings.Compare(m.Comment(), other.Comment())
+}
+
+// memberKeys returns the sorted, distinct keys of the mailboxes of the group.
+func (g *Group) memberKeys(mode EqualMode) []string {
+	seen := make(map[string]struct{}, len(g.mailboxList))
+	ks := make([]string, 0, len(g.mailboxList))
+	for _, mb := range g.mailboxList {
+		k := mb.KeyFor(mode)
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	return ks
+}
+
+// KeyFor returns a string that is identical for any two groups that are equal
+// under the given mode and different otherwise. The mailboxes of the group are
+// treated as a set, so their order and any duplicates are ignored. The display
+// name of the group is only considered under EqualFull.
+func (g *Group) KeyFor(mode EqualMode) string {
+	k := strings.Join(g.memberKeys(mode), "\x01")
+	if mode != EqualFull {
+		return k
+	}
+
+	return canonicalText(g.displayName) + "\x02" + k
+}
+
+// Key returns a string identifying the set of mailboxes in the group, which is
+// suitable for use as a map key. This is the same as KeyFor(EqualCanonical).
+func (g *Group) Key() string { return g.KeyFor(EqualCanonical) }
+
+// Equal returns true if the two groups contain the same set of mailboxes under
+// the given mode. Under EqualFull, the display names must also be equal.
+func (g *Group) Equal(other *Group, mode EqualMode) bool {
+	return g.KeyFor(mode) == other.KeyFor(mode)
+}
+
+// Compare returns -1, 0, or 1 as the group sorts before, the same as, or after
+// the other group. Groups are ordered by display name and then by their sets of
+// mailboxes.
+func (g *Group) Compare(other *Group) int {
+	if c := strings.Compare(g.displayName, other.displayName); c != 0 {
+		return c
+	}
+
+	return strings.Compare(g.KeyFor(EqualExact), other.KeyFor(EqualExact))
+}
+
+// Compare returns -1, 0, or 1 as the first address sorts before, the same as,
+// or after the second, which is suitable for sorting an AddressList. Mailboxes
+// and bare AddrSpecs are ordered as by Mailbox.Compare and come before groups,
+// which are ordered as by Group.Compare. Any other Address comes last and is
+// ordered by CleanString.
+func Compare(a, b Address) int {
+	ra, rb := compareRank(a), compareRank(b)
+	if ra != rb {
+		if ra < rb {
+			return -1
+		}
+		return 1
+	}
+
+	switch ra {
+	case 0:
+		return asMailbox(a).Compare(asMailbox(b))
+	case 1:
+		return a.(*Group).Compare(b.(*Group))
+	default:
+		return strings.Compare(a.CleanString(), b.CleanString())
+	}
+}
+
+// compareRank returns the rank of the address type used by Compare.
+func compareRank(a Address) int {
+	switch a.(type) {
+	case *Mailbox, *AddrSpec:
+		return 0
+	case *Group:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// asMailbox returns the address as a mailbox, promoting a bare AddrSpec. Any
+// other Address is converted as is done by AddressList.Flatten, which returns
+// nil if its address does not parse.
+func asMailbox(a Address) *Mailbox {
+	switch v := a.(type) {
+	case *Mailbox:
+		return v
+	case *AddrSpec:
+		return &Mailbox{address: v}
+	default:
+		mb, _ := NewMailboxStr(a.DisplayName(), a.Address(), a.Comment())
+		return mb
+	}
+}
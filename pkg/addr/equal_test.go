@@ -0,0 +1,114 @@
+package addr
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddrSpecEqual(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		a, b                        *AddrSpec
+		exact, fold, canonical, all bool
+	}{
+		{NewAddrSpec("john", "example.com"), NewAddrSpec("john", "example.com"), true, true, true, true},
+		{NewAddrSpec("john", "example.com"), NewAddrSpec("john", "EXAMPLE.com"), false, true, true, true},
+		{NewAddrSpec("john", "example.com"), NewAddrSpec("John", "example.com"), false, false, false, false},
+		{NewAddrSpec("café", "example.com"), NewAddrSpec("café", "example.com"), false, false, true, true},
+		{NewAddrSpec("john", "bücher.example"), NewAddrSpec("john", "xn--bcher-kva.example"), false, false, true, true},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.exact, tc.a.Equal(tc.b, EqualExact), "exact %s %s", tc.a, tc.b)
+		assert.Equal(t, tc.fold, tc.a.Equal(tc.b, EqualDomainFold), "fold %s %s", tc.a, tc.b)
+		assert.Equal(t, tc.canonical, tc.a.Equal(tc.b, EqualCanonical), "canonical %s %s", tc.a, tc.b)
+		assert.Equal(t, tc.all, tc.a.Equal(tc.b, EqualFull), "full %s %s", tc.a, tc.b)
+		assert.Equal(t, tc.canonical, tc.a.Key() == tc.b.Key(), "key %s %s", tc.a, tc.b)
+	}
+}
+
+func TestMailboxEqual(t *testing.T) {
+	t.Parallel()
+
+	a, err := ParseEmailMailbox(`"John  Smith" <"john"@Example.com> (boss)`)
+	assert.NoError(t, err)
+
+	b, err := NewMailboxStr("John Smith", "john@example.com", "boss")
+	assert.NoError(t, err)
+
+	c, err := NewMailboxStr("Johnny", "john@example.com", "")
+	assert.NoError(t, err)
+
+	assert.False(t, a.Equal(b, EqualExact))
+	assert.True(t, a.Equal(b, EqualDomainFold))
+	assert.True(t, a.Equal(b, EqualCanonical))
+	assert.True(t, a.Equal(b, EqualFull))
+
+	assert.True(t, b.Equal(c, EqualCanonical))
+	assert.False(t, b.Equal(c, EqualFull))
+
+	assert.Equal(t, "john@example.com", a.Key())
+
+	seen := map[string]bool{}
+	for _, mb := range []*Mailbox{a, b, c} {
+		seen[mb.Key()] = true
+	}
+	assert.Len(t, seen, 1)
+}
+
+func TestGroupEqual(t *testing.T) {
+	t.Parallel()
+
+	a, err := ParseEmailGroup("Friends: ender@example.com, bean@example.com;")
+	assert.NoError(t, err)
+
+	b, err := ParseEmailGroup("Pals: Bean <bean@EXAMPLE.com>, ender@example.com, ender@example.com;")
+	assert.NoError(t, err)
+
+	c, err := ParseEmailGroup("Friends: ender@example.com;")
+	assert.NoError(t, err)
+
+	assert.True(t, a.Equal(b, EqualCanonical))
+	assert.False(t, a.Equal(b, EqualExact))
+	assert.False(t, a.Equal(b, EqualFull))
+	assert.False(t, a.Equal(c, EqualCanonical))
+	assert.Equal(t, a.Key(), b.Key())
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	al, err := ParseEmailAddressList(
+		"Zed: z@example.com;, b@b.example, B <a@B.example>, a@c.example, Amy: ;, A <a@b.example>, a@b.example")
+	assert.NoError(t, err)
+
+	sort.SliceStable(al, func(i, j int) bool { return Compare(al[i], al[j]) < 0 })
+
+	ss := make([]string, len(al))
+	for i, a := range al {
+		ss[i] = a.CleanString()
+	}
+
+	assert.Equal(t, []string{
+		"B <a@B.example>",
+		"a@b.example",
+		"A <a@b.example>",
+		"b@b.example",
+		"a@c.example",
+		"Amy:;",
+		"Zed: z@example.com;",
+	}, ss)
+
+	for _, a := range al {
+		assert.Equal(t, 0, Compare(a, a))
+	}
+
+	as := NewAddrSpec("a", "b.example")
+	assert.Equal(t, 1, as.Compare(NewAddrSpec("a", "a.example")))
+	assert.Equal(t, 1, as.Compare(NewAddrSpec("a", "B.example")))
+	assert.Equal(t, -1, as.Compare(NewAddrSpec("b", "B.example")))
+	assert.Equal(t, 0, as.Compare(NewAddrSpec("a", "b.example")))
+}
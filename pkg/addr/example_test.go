@@ -87,3 +87,28 @@ func ExampleMailbox_convertToMailAddress() {
 	fmt.Println(mailmb)
 	// Output: {David Weber honorh@example.com}
 }
+
+// This example shows how a Formatter can be used to output a mailbox list in
+// the legacy style with the display name in a comment.
+func ExampleFormatter() {
+	mbs, _ := addr.ParseEmailMailboxList("\"J.R.R. Tolkein\" <j.r.r.tolkein@example.com>, \"C.S. Lewis\" <jack@example.com>")
+	f := addr.Formatter{Style: addr.StyleAddrName}
+	fmt.Println(f.FormatMailboxList(mbs))
+	// Output:
+	// j.r.r.tolkein@example.com (J.R.R. Tolkein), jack@example.com (C.S. Lewis)
+}
+
+// This example shows how a ParsedAddressList can be used to change a single
+// address without disturbing the rest of the original text.
+func ExampleParsedAddressList() {
+	addresses := ", (weird stuff), \"J.R.R. Tolkein\" <j.r.r.tolkein@example.com>, \"C.S. Lewis\" <jack@example.com>, (wacky)"
+	pal, _ := addr.ParseEmailAddressListPreserving(addresses)
+	fmt.Println(pal.OriginalString())
+
+	mb, _ := addr.NewMailboxStr("Clive Staples Lewis", "jack@example.com", "")
+	pal.Set(1, mb)
+	fmt.Println(pal.OriginalString())
+	// Output:
+	// , (weird stuff), "J.R.R. Tolkein" <j.r.r.tolkein@example.com>, "C.S. Lewis" <jack@example.com>, (wacky)
+	// , (weird stuff), "J.R.R. Tolkein" <j.r.r.tolkein@example.com>, "Clive Staples Lewis" <jack@example.com>, (wacky)
+}
@@ -0,0 +1,185 @@
+package addr
+
+import (
+	"strings"
+
+	"github.com/zostay/go-addr/pkg/format"
+)
+
+// Style selects the overall shape of each mailbox output by a Formatter.
+type Style int
+
+// These are the available styles. The examples show a mailbox with display
+// name "Name", address "addr@example.com", and comment "note".
+const (
+	// StyleNameAddrComment is the style used by CleanString:
+	//  Name <addr@example.com> (note)
+	StyleNameAddrComment Style = iota
+
+	// StyleNameAddr drops the comment:
+	//  Name <addr@example.com>
+	StyleNameAddr
+
+	// StyleAddrName is the legacy style with the display name in a comment.
+	// If there is no display name, the comment is used instead:
+	//  addr@example.com (Name)
+	StyleAddrName
+
+	// StyleAddrOnly outputs only the bare address:
+	//  addr@example.com
+	StyleAddrOnly
+)
+
+// Formatter renders addresses in one of several styles. It applies the same
+// style to Mailbox, Group, AddressList, and MailboxList values and to the
+// mailboxes within each group. The zero value renders identically to
+// CleanString.
+//
+// The output is built from the rendering functions of the format package, so
+// display names and comments are always quoted, escaped, or encoded correctly.
+//
+// Unsafe characters (see format.IsUnsafeRune) are never output. They are
+// dropped from display names, comments, and addresses, and the original string
+// of a value that fails CheckSafe is ignored as if PreferOriginal were not set. Since these
+// values cannot be rendered faithfully, use CheckSafe to reject them first or
+// render with AddressList.FoldedHeader, which returns an error for them.
+type Formatter struct {
+	// Style selects the shape of each mailbox.
+	Style Style
+
+	// QuoteDisplayName causes display names to always be quoted, even when
+	// they contain only atoms. Display names that must be encoded as MIME
+	// words are still encoded.
+	QuoteDisplayName bool
+
+	// PreferOriginal causes the original string to be output for any value
+	// that has one, i.e., any value that was parsed and has not been modified
+	// since. A mailbox whose address has been modified in place is treated as
+	// modified. Values without an original are rendered using the Style. A group
+	// containing a modified mailbox is rendered using the Style, but its
+	// unmodified mailboxes will still use their originals.
+	PreferOriginal bool
+}
+
+// phrase renders a display name according to the formatter settings.
+func (f Formatter) phrase(dn string) string {
+	dn = format.StripUnsafe(dn)
+	if f.QuoteDisplayName && !format.NeedsWordEncoding(dn) {
+		return format.QuoteString(dn)
+	}
+
+	return format.RenderPhrase(dn)
+}
+
+// comment renders comment text according to the formatter settings.
+func (f Formatter) comment(c string) string {
+	return format.RenderComment(format.StripUnsafe(c))
+}
+
+// original returns true if the formatter should output the original string.
+// The safe argument is the result of checking the value with CheckSafe.
+func (f Formatter) original(o string, safe error) bool {
+	return f.PreferOriginal && o != "" && safe == nil && format.IndexUnsafe(o) < 0
+}
+
+// FormatMailbox renders the mailbox.
+func (f Formatter) FormatMailbox(m *Mailbox) string {
+	if m.unchanged() && f.original(m.original, m.CheckSafe()) {
+		return m.original
+	}
+
+	var a strings.Builder
+	as := m.address.CleanString()
+
+	switch f.Style {
+	case StyleAddrName:
+		a.WriteString(as)
+		if m.displayName != "" {
+			a.WriteString(" ")
+			a.WriteString(f.comment(m.displayName))
+		} else if c := m.Comment(); c != "" {
+			a.WriteString(" ")
+			a.WriteString(f.comment(c))
+		}
+	case StyleAddrOnly:
+		a.WriteString(as)
+	default:
+		if m.displayName != "" {
+			a.WriteString(f.phrase(m.displayName))
+			a.WriteString(" <")
+			a.WriteString(as)
+			a.WriteString(">")
+		} else {
+			a.WriteString(as)
+		}
+
+		if c := m.Comment(); f.Style == StyleNameAddrComment && c != "" {
+			a.WriteString(" ")
+			a.WriteString(f.comment(c))
+		}
+	}
+
+	return a.String()
+}
+
+// FormatGroup renders the group. The group syntax is always kept, even with
+// StyleAddrOnly, and each mailbox within the group is rendered using
+// FormatMailbox.
+func (f Formatter) FormatGroup(g *Group) string {
+	if f.PreferOriginal && g.unchanged() && g.CheckSafe() == nil {
+		return g.original
+	}
+
+	var a strings.Builder
+	a.WriteString(f.phrase(g.displayName))
+	a.WriteString(":")
+	if len(g.mailboxList) > 0 {
+		a.WriteString(" ")
+		a.WriteString(f.FormatMailboxList(g.mailboxList))
+	}
+	a.WriteString(";")
+	return a.String()
+}
+
+// Format renders any address. A *Mailbox is rendered with FormatMailbox, a
+// *Group with FormatGroup, and an *AddrSpec as a mailbox with no display name
+// or comment. Any other Address is rendered using its OriginalString or
+// CleanString.
+func (f Formatter) Format(a Address) string {
+	switch v := a.(type) {
+	case *Mailbox:
+		return f.FormatMailbox(v)
+	case *Group:
+		return f.FormatGroup(v)
+	case *AddrSpec:
+		if f.original(v.original, v.CheckSafe()) {
+			return v.original
+		}
+		return v.CleanString()
+	default:
+		if f.original(a.OriginalString(), AddressList{a}.CheckSafe()) {
+			return a.OriginalString()
+		}
+		return a.CleanString()
+	}
+}
+
+// FormatAddressList renders each address in the list using Format and joins
+// them with a comma and a space.
+func (f Formatter) FormatAddressList(as AddressList) string {
+	ss := make([]string, len(as))
+	for i, a := range as {
+		ss[i] = f.Format(a)
+	}
+	return strings.Join(ss, ", ")
+}
+
+// FormatMailboxList renders each mailbox in the list using FormatMailbox and
+// joins them with a comma and a space.
+func (f Formatter) FormatMailboxList(ms MailboxList) string {
+	ss := make([]string, len(ms))
+	for i, m := range ms {
+		ss[i] = f.FormatMailbox(m)
+	}
+	return strings.Join(ss, ", ")
+}
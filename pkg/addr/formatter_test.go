@@ -0,0 +1,66 @@
+package addr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatterMailbox(t *testing.T) {
+	t.Parallel()
+
+	mb, err := NewMailboxStr("Name", "addr@example.com", "note")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Name <addr@example.com> (note)", Formatter{}.FormatMailbox(mb))
+	assert.Equal(t, mb.CleanString(), Formatter{}.FormatMailbox(mb))
+	assert.Equal(t, "Name <addr@example.com>", Formatter{Style: StyleNameAddr}.FormatMailbox(mb))
+	assert.Equal(t, "addr@example.com (Name)", Formatter{Style: StyleAddrName}.FormatMailbox(mb))
+	assert.Equal(t, "addr@example.com", Formatter{Style: StyleAddrOnly}.FormatMailbox(mb))
+	assert.Equal(t, "\"Name\" <addr@example.com>", Formatter{Style: StyleNameAddr, QuoteDisplayName: true}.FormatMailbox(mb))
+
+	nn, err := NewMailboxStr("", "addr@example.com", "note")
+	assert.NoError(t, err)
+	assert.Equal(t, "addr@example.com (note)", Formatter{Style: StyleAddrName}.FormatMailbox(nn))
+	assert.Equal(t, "addr@example.com", Formatter{Style: StyleNameAddr}.FormatMailbox(nn))
+
+	un, err := NewMailboxStr("Ünternehmen", "addr@example.com", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "=?utf-8?q?=C3=9Cnternehmen?= <addr@example.com>", Formatter{QuoteDisplayName: true}.FormatMailbox(un))
+	assert.Equal(t, "addr@example.com (=?utf-8?q?=C3=9Cnternehmen?=)", Formatter{Style: StyleAddrName}.FormatMailbox(un))
+}
+
+func TestFormatterPreferOriginal(t *testing.T) {
+	t.Parallel()
+
+	const orig = "\"Orson Scott Card\" <ender(weird comment placement)@example.com>"
+	mb, err := ParseEmailMailbox(orig)
+	assert.NoError(t, err)
+
+	f := Formatter{Style: StyleAddrOnly, PreferOriginal: true}
+	assert.Equal(t, orig, f.FormatMailbox(mb))
+
+	mb.SetDisplayName("Ender")
+	assert.Equal(t, "ender@example.com", f.FormatMailbox(mb))
+}
+
+func TestFormatterLists(t *testing.T) {
+	t.Parallel()
+
+	al, err := ParseEmailAddressList("Team: \"A\" <a@example.com> (x), b@example.com;, \"C\" <c@example.com>")
+	assert.NoError(t, err)
+
+	assert.Equal(t,
+		"Team: a@example.com, b@example.com;, c@example.com",
+		Formatter{Style: StyleAddrOnly}.FormatAddressList(al),
+	)
+	assert.Equal(t,
+		"\"Team\": \"A\" <a@example.com>, b@example.com;, \"C\" <c@example.com>",
+		Formatter{Style: StyleNameAddr, QuoteDisplayName: true}.FormatAddressList(al),
+	)
+	assert.Equal(t,
+		"a@example.com (A), b@example.com",
+		Formatter{Style: StyleAddrName}.FormatMailboxList(al[0].(*Group).MailboxList()),
+	)
+	assert.Equal(t, al.CleanString(), Formatter{}.FormatAddressList(al))
+}
@@ -11,16 +11,33 @@ type Group struct {
 	displayName string
 	mailboxList MailboxList
 	original    string
+	comments    []Comment
 }
 
 // DisplayName returns the display name of the group of email addresses.
 func (g *Group) DisplayName() string { return g.displayName }
 
 // SetDisplayName updates the display name. It will also clear the original
-// string if one is set.
+// string if one is set and drop any comments found around the old display
+// name. The display name is not checked for unsafe characters; use
+// TrySetDisplayName for that.
 func (g *Group) SetDisplayName(dn string) {
 	g.displayName = dn
 	g.original = ""
+	g.comments = dropComments(g.comments,
+		CommentBeforeDisplayName, CommentInDisplayName, CommentAfterDisplayName)
+}
+
+// TrySetDisplayName works like SetDisplayName, except that if the display name
+// contains a control character or bidi override, the group is left unchanged
+// and an *UnsafeTextError is returned.
+func (g *Group) TrySetDisplayName(dn string) error {
+	if err := checkSafeText("group display name", dn); err != nil {
+		return err
+	}
+
+	g.SetDisplayName(dn)
+	return nil
 }
 
 // MailboxList returns the slice of mailbox address for this group.
@@ -40,8 +57,10 @@ func (g *Group) SetMailboxList(mbs MailboxList) {
 // Address returns the CleanString for the MailboxList.
 func (g *Group) Address() string { return g.MailboxList().String() }
 
-// Comment always returns the empty string.
-func (g *Group) Comment() string { return "" }
+// Comment returns the text of the comments of the group joined with spaces or
+// an empty string if there are none. This does not include the comments of the
+// mailboxes within the group. See Comments for the individual comments.
+func (g *Group) Comment() string { return joinComments(g.comments) }
 
 // OriginalString will return the originally parsed string, if that string is
 // set. This is useful for roundtripping.
@@ -49,29 +68,58 @@ func (g *Group) OriginalString() string {
 	return g.original
 }
 
-// CleanString returns the canonical version of the group email address string.
-func (g *Group) CleanString() string {
-	var a strings.Builder
-	a.WriteString(g.displayName)
-	a.WriteString(": ")
-	first := true
+// unchanged returns true if the group has an original string and neither the
+// group nor any of its mailboxes have been modified since it was parsed.
+func (g *Group) unchanged() bool {
+	if g.original == "" {
+		return false
+	}
+
 	for _, mb := range g.mailboxList {
-		if !first {
-			a.WriteString(", ")
+		if !mb.unchanged() {
+			return false
 		}
-		a.WriteString(mb.CleanString())
-		first = false
 	}
-	a.WriteString(";")
-	return a.String()
+
+	return true
+}
+
+// CleanString returns the canonical version of the group email address string.
+// The display name is quoted, escaped, or encoded as MIME words as needed (see
+// format.RenderPhrase) and each mailbox is rendered with its own CleanString.
+// Parsing the returned string will produce an equivalent group, unless it
+// contains unsafe characters, which are dropped (see Mailbox.CleanString).
+func (g *Group) CleanString() string {
+	return Formatter{}.FormatGroup(g)
 }
 
-// String is an alias for CleanString.
+// String is an alias for OriginalString.
 func (g *Group) String() string { return g.OriginalString() }
 
+// NewGroup constructs and returns a group email address with the given display
+// name and mailboxes.
+//
+// This will return an *UnsafeTextError if the display name contains a control
+// character or bidi override (see format.IsUnsafeRune).
+func NewGroup(dn string, l MailboxList) (*Group, error) {
+	if err := checkSafeText("group display name", dn); err != nil {
+		return nil, err
+	}
+
+	return NewGroupParsed(dn, l, ""), nil
+}
+
 // NewGroupParsed constructs and returns a group email address with an
 // associated original string.
+//
+// Unlike NewGroup, unsafe characters are permitted in the display name as these
+// may be found in parsed email. Use CheckSafe or StripUnsafe before using such
+// a group in a new message.
 func NewGroupParsed(dn string, l MailboxList, o string) *Group {
+	if l == nil {
+		l = MailboxList{}
+	}
+
 	return &Group{
 		displayName: dn,
 		mailboxList: l,
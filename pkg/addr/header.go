@@ -0,0 +1,292 @@
+package addr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// These are the kinds of violation reported by HeaderFields.Validate. The Part
+// of each is the name of the header field in violation.
+const (
+	ViolationMissingField    = "missing field"
+	ViolationDuplicateField  = "duplicate field"
+	ViolationEmptyField      = "empty field"
+	ViolationGroupInField    = "group not permitted"
+	ViolationMultipleSenders = "more than one sender"
+	ViolationSenderRequired  = "sender required for more than one author"
+	ViolationRedundantSender = "sender is the same as the author"
+)
+
+// HeaderFields holds the originator and destination fields of a message, as
+// described in RFC 5322 sections 3.6.2, 3.6.3, and 3.6.6. A nil list means the
+// field is absent. An empty, non-nil list means the field is present but lists
+// no addresses, which is only permitted for Bcc and Resent-Bcc.
+type HeaderFields struct {
+	From    AddressList
+	Sender  AddressList
+	ReplyTo AddressList
+	To      AddressList
+	Cc      AddressList
+	Bcc     AddressList
+
+	// Resent holds each block of resent fields, in the order they appear.
+	Resent []ResentFields
+
+	duplicates []string // names of fields found more than once by ParseHeaderFields
+}
+
+// ResentFields holds a single block of resent fields, as described in RFC 5322
+// section 3.6.6. Each block is added when a message is reintroduced into the
+// transport system by a user.
+type ResentFields struct {
+	Date   bool // true if the block has a Resent-Date field
+	From   AddressList
+	Sender AddressList
+	To     AddressList
+	Cc     AddressList
+	Bcc    AddressList
+}
+
+// headerFieldList returns a pointer to the address list of the HeaderFields
+// that holds the named field or nil if the field is not an originator or
+// destination field.
+func (h *HeaderFields) headerFieldList(name string) *AddressList {
+	switch strings.ToLower(name) {
+	case "from":
+		return &h.From
+	case "sender":
+		return &h.Sender
+	case "reply-to":
+		return &h.ReplyTo
+	case "to":
+		return &h.To
+	case "cc":
+		return &h.Cc
+	case "bcc":
+		return &h.Bcc
+	default:
+		return nil
+	}
+}
+
+// resentFieldList returns a pointer to the address list of the ResentFields
+// that holds the named field or nil if the field is not a resent address
+// field.
+func (r *ResentFields) resentFieldList(name string) *AddressList {
+	switch strings.ToLower(name) {
+	case "resent-from":
+		return &r.From
+	case "resent-sender":
+		return &r.Sender
+	case "resent-to":
+		return &r.To
+	case "resent-cc":
+		return &r.Cc
+	case "resent-bcc":
+		return &r.Bcc
+	default:
+		return nil
+	}
+}
+
+// unfoldHeader splits a header block into unfolded fields, stopping at the
+// first empty line.
+func unfoldHeader(header string) []string {
+	var fields []string
+	for _, line := range strings.Split(strings.ReplaceAll(header, "\r\n", "\n"), "\n") {
+		switch {
+		case line == "":
+			return fields
+		case (line[0] == ' ' || line[0] == '\t') && len(fields) > 0:
+			fields[len(fields)-1] += line
+		default:
+			fields = append(fields, line)
+		}
+	}
+
+	return fields
+}
+
+// parseFieldList parses the body of an address field. An empty body results in
+// an empty, non-nil list.
+func parseFieldList(name, body string) (AddressList, error) {
+	if strings.TrimSpace(body) == "" {
+		return AddressList{}, nil
+	}
+
+	al, err := ParseEmailAddressList(body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return al, nil
+}
+
+// ParseHeaderFields parses the originator and destination fields from a
+// message header block, with lines separated by either CRLF or LF. Parsing
+// stops at the first empty line, so a whole message may be given. Fields other
+// than those held by HeaderFields are ignored.
+//
+// Resent fields are grouped into blocks: a block ends when a field other than
+// a resent field follows or a resent field is repeated. Fields found more than
+// once are reported by Validate.
+//
+// An error is returned if the addresses of any field cannot be parsed.
+func ParseHeaderFields(header string) (*HeaderFields, error) {
+	h := &HeaderFields{}
+
+	var (
+		resent *ResentFields
+		seen   = map[string]bool{}
+	)
+	for _, field := range unfoldHeader(header) {
+		i := strings.IndexByte(field, ':')
+		if i < 0 {
+			continue
+		}
+
+		name, body := strings.TrimSpace(field[:i]), field[i+1:]
+		key := strings.ToLower(name)
+
+		if !strings.HasPrefix(key, "resent-") {
+			resent = nil
+		} else if resent == nil || seen[key] {
+			h.Resent = append(h.Resent, ResentFields{})
+			resent = &h.Resent[len(h.Resent)-1]
+			for k := range seen {
+				if strings.HasPrefix(k, "resent-") {
+					delete(seen, k)
+				}
+			}
+		}
+
+		var list *AddressList
+		switch {
+		case key == "resent-date":
+			resent.Date = true
+		case resent != nil:
+			list = resent.resentFieldList(name)
+		default:
+			list = h.headerFieldList(name)
+		}
+
+		if seen[key] && list != nil {
+			h.duplicates = append(h.duplicates, name)
+		}
+		seen[key] = true
+
+		if list == nil {
+			continue
+		}
+
+		al, err := parseFieldList(name, body)
+		if err != nil {
+			return nil, err
+		}
+
+		*list = append(*list, al...)
+		if *list == nil {
+			*list = AddressList{}
+		}
+	}
+
+	return h, nil
+}
+
+// fieldViolations returns the violations of a single address field. A field
+// holding a mailbox list may not contain groups. A field that is not
+// permitted to be empty must list at least one address.
+func fieldViolations(name string, al AddressList, mailboxList, mayBeEmpty bool) []Violation {
+	if al == nil {
+		return nil
+	}
+
+	if len(al) == 0 && !mayBeEmpty {
+		return []Violation{{Kind: ViolationEmptyField, Part: name}}
+	}
+
+	var vs []Violation
+	if mailboxList {
+		for _, a := range al {
+			if _, ok := a.(*Group); ok {
+				vs = append(vs, Violation{Kind: ViolationGroupInField, Part: name})
+				break
+			}
+		}
+	}
+
+	return vs
+}
+
+// originatorViolations returns the violations of the rules relating an author
+// field, From or Resent-From, to its sender field, Sender or Resent-Sender.
+func originatorViolations(fromName string, from AddressList, senderName string, sender AddressList) []Violation {
+	var vs []Violation
+	if from == nil {
+		vs = append(vs, Violation{Kind: ViolationMissingField, Part: fromName})
+	}
+	vs = append(vs, fieldViolations(fromName, from, true, false)...)
+
+	vs = append(vs, fieldViolations(senderName, sender, true, false)...)
+	if len(sender) > 1 || len(sender.Flatten()) > 1 {
+		vs = append(vs, Violation{Kind: ViolationMultipleSenders, Part: senderName})
+	}
+
+	authors := from.Flatten()
+	switch {
+	case len(authors) > 1 && len(sender) == 0:
+		vs = append(vs, Violation{Kind: ViolationSenderRequired, Part: senderName})
+	case len(authors) == 1 && len(sender) == 1:
+		if mb, ok := sender[0].(*Mailbox); ok && mb.address.Equal(authors[0].address, EqualCanonical) {
+			vs = append(vs, Violation{Kind: ViolationRedundantSender, Part: senderName})
+		}
+	}
+
+	return vs
+}
+
+// Violations returns every violation of the rules of RFC 5322 sections 3.6.2,
+// 3.6.3, and 3.6.6 that relate the header fields:
+//
+//   - From is required and may list only mailboxes, not groups.
+//   - Sender is required when From lists more than one mailbox. It must be a
+//     single mailbox and should not be used when it is the same as the only
+//     mailbox of From.
+//   - Reply-To, To, and Cc must list at least one address when present, but
+//     Bcc may be empty.
+//   - No field may appear more than once.
+//   - Each block of resent fields must have Resent-Date and Resent-From, and
+//     the same rules apply to Resent-From, Resent-Sender, Resent-To,
+//     Resent-Cc, and Resent-Bcc as to the fields they correspond to.
+//
+// The addresses themselves are not checked. See AddressList.Validate for that.
+func (h *HeaderFields) Violations() []Violation {
+	var vs []Violation
+	for _, name := range h.duplicates {
+		vs = append(vs, Violation{Kind: ViolationDuplicateField, Part: name})
+	}
+
+	vs = append(vs, originatorViolations("From", h.From, "Sender", h.Sender)...)
+	vs = append(vs, fieldViolations("Reply-To", h.ReplyTo, false, false)...)
+	vs = append(vs, fieldViolations("To", h.To, false, false)...)
+	vs = append(vs, fieldViolations("Cc", h.Cc, false, false)...)
+	vs = append(vs, fieldViolations("Bcc", h.Bcc, false, true)...)
+
+	for _, r := range h.Resent {
+		if !r.Date {
+			vs = append(vs, Violation{Kind: ViolationMissingField, Part: "Resent-Date"})
+		}
+		vs = append(vs, originatorViolations("Resent-From", r.From, "Resent-Sender", r.Sender)...)
+		vs = append(vs, fieldViolations("Resent-To", r.To, false, false)...)
+		vs = append(vs, fieldViolations("Resent-Cc", r.Cc, false, false)...)
+		vs = append(vs, fieldViolations("Resent-Bcc", r.Bcc, false, true)...)
+	}
+
+	return vs
+}
+
+// Validate checks the header fields as described for Violations. If anything
+// is wrong, a *ValidationError listing every violation is returned.
+func (h *HeaderFields) Validate() error {
+	return validationError(h.Violations())
+}
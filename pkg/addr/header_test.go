@@ -0,0 +1,107 @@
+package addr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHeaderFields(t *testing.T) {
+	t.Parallel()
+
+	h, err := ParseHeaderFields("Resent-Date: Mon, 1 Mar 2021 10:00:00 +0000\r\n" +
+		"Resent-From: alice@example.com\r\n" +
+		"Resent-To: bob@example.com\r\n" +
+		"Resent-Date: Sun, 28 Feb 2021 10:00:00 +0000\r\n" +
+		"Resent-From: carol@example.com\r\n" +
+		"From: John Smith <john@example.com>,\r\n" +
+		" jane@example.com\r\n" +
+		"Sender: secretary@example.com\r\n" +
+		"Subject: Hello\r\n" +
+		"To: Friends: mary@example.net, sam@example.net;\r\n" +
+		"Bcc:\r\n" +
+		"\r\n" +
+		"Cc: not@example.com\r\n")
+	assert.NoError(t, err)
+
+	assert.Equal(t, `"John Smith" <john@example.com>, jane@example.com`, h.From.String())
+	assert.Equal(t, "secretary@example.com", h.Sender.String())
+	assert.Equal(t, "Friends: mary@example.net, sam@example.net;", h.To.String())
+	assert.NotNil(t, h.Bcc)
+	assert.Empty(t, h.Bcc)
+	assert.Nil(t, h.Cc)
+	assert.Nil(t, h.ReplyTo)
+
+	if assert.Len(t, h.Resent, 2) {
+		assert.True(t, h.Resent[0].Date)
+		assert.Equal(t, "alice@example.com", h.Resent[0].From.String())
+		assert.Equal(t, "bob@example.com", h.Resent[0].To.String())
+		assert.True(t, h.Resent[1].Date)
+		assert.Equal(t, "carol@example.com", h.Resent[1].From.String())
+		assert.Nil(t, h.Resent[1].To)
+	}
+
+	assert.NoError(t, h.Validate())
+
+	_, err = ParseHeaderFields("From: @@@\n")
+	assert.Error(t, err)
+}
+
+func TestHeaderFieldsViolations(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		header     string
+		violations []Violation
+	}{
+		{"From: john@example.com\nTo: jane@example.com\n", nil},
+		{"To: jane@example.com\n", []Violation{{Kind: ViolationMissingField, Part: "From"}}},
+		{"From: a@example.com, b@example.com\n", []Violation{{Kind: ViolationSenderRequired, Part: "Sender"}}},
+		{"From: a@example.com, b@example.com\nSender: a@example.com\n", nil},
+		{"From: a@example.com\nSender: Team: b@example.com, c@example.com;\n", []Violation{
+			{Kind: ViolationGroupInField, Part: "Sender"},
+			{Kind: ViolationMultipleSenders, Part: "Sender"},
+		}},
+		{"From: a@example.com\nSender: A <a@Example.COM>\n", []Violation{{Kind: ViolationRedundantSender, Part: "Sender"}}},
+		{"From: Team: a@example.com;\n", []Violation{{Kind: ViolationGroupInField, Part: "From"}}},
+		{"From: a@example.com\nTo:\nCc:\nReply-To:\nBcc:\n", []Violation{
+			{Kind: ViolationEmptyField, Part: "Reply-To"},
+			{Kind: ViolationEmptyField, Part: "To"},
+			{Kind: ViolationEmptyField, Part: "Cc"},
+		}},
+		{"From: a@example.com\nFrom: b@example.com\n", []Violation{
+			{Kind: ViolationDuplicateField, Part: "From"},
+			{Kind: ViolationSenderRequired, Part: "Sender"},
+		}},
+		{"From: a@example.com\nResent-To: b@example.com\n", []Violation{
+			{Kind: ViolationMissingField, Part: "Resent-Date"},
+			{Kind: ViolationMissingField, Part: "Resent-From"},
+		}},
+		{"Resent-Date: Mon, 1 Mar 2021 10:00:00 +0000\nResent-From: a@example.com, b@example.com\nFrom: a@example.com\n", []Violation{
+			{Kind: ViolationSenderRequired, Part: "Resent-Sender"},
+		}},
+		{"Resent-Date: Mon, 1 Mar 2021 10:00:00 +0000\nResent-From: a@example.com\nResent-Bcc:\nFrom: a@example.com\n", nil},
+	}
+
+	for _, tc := range tests {
+		h, err := ParseHeaderFields(tc.header)
+		if assert.NoError(t, err, tc.header) {
+			assert.Equal(t, tc.violations, h.Violations(), tc.header)
+		}
+	}
+}
+
+func TestHeaderFieldsValidate(t *testing.T) {
+	t.Parallel()
+
+	from := mustParseList(t, "a@example.com, b@example.com")
+	h := &HeaderFields{From: from, Bcc: AddressList{}}
+	err := h.Validate()
+	assert.Equal(t, []string{ViolationSenderRequired}, violationKinds(t, err))
+	if assert.IsType(t, &ValidationError{}, err) {
+		assert.Equal(t, `sender required for more than one author: "Sender"`, err.Error())
+	}
+
+	h.Sender = from[:1]
+	assert.NoError(t, h.Validate())
+}
@@ -0,0 +1,74 @@
+package addr
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mustParseList(t *testing.T, s string) AddressList {
+	t.Helper()
+
+	al, err := ParseEmailAddressList(s)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	return al
+}
+
+func mustMailbox(t *testing.T, dn, lp, d string) *Mailbox {
+	t.Helper()
+
+	mb, err := NewMailbox(dn, NewAddrSpec(lp, d), "")
+	assert.NoError(t, err)
+	return mb
+}
+
+func violationKinds(t *testing.T, err error) []string {
+	t.Helper()
+
+	var ve *ValidationError
+	if !assert.True(t, errors.As(err, &ve)) {
+		return nil
+	}
+
+	ks := make([]string, len(ve.Violations))
+	for i, v := range ve.Violations {
+		ks[i] = v.Kind
+	}
+	return ks
+}
+
+func addrSpecStrings(as []*AddrSpec) []string {
+	ss := make([]string, len(as))
+	for i, a := range as {
+		ss[i] = a.CleanString()
+	}
+	return ss
+}
+
+func diagnosticCodes(ds Diagnosis) []DiagnosticCode {
+	cs := make([]DiagnosticCode, len(ds))
+	for i, d := range ds {
+		cs[i] = d.Code
+	}
+	return cs
+}
+
+func spoofKinds(fs []SpoofFinding) []SpoofKind {
+	ks := make([]SpoofKind, len(fs))
+	for i, f := range fs {
+		ks[i] = f.Kind
+	}
+	return ks
+}
+
+func suggestedDomains(ss []Suggestion) []string {
+	ds := make([]string, len(ss))
+	for i, s := range ss {
+		ds[i] = s.Domain
+	}
+	return ds
+}
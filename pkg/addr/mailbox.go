@@ -2,10 +2,8 @@ package addr
 
 import (
 	"errors"
-	"mime"
 	"strings"
 
-	"github.com/zostay/go-addr/pkg/format"
 	"github.com/zostay/go-addr/pkg/rfc5322"
 )
 
@@ -23,8 +21,8 @@ var (
 type Mailbox struct {
 	displayName string
 	address     *AddrSpec
-	comment     string
 	original    string
+	comments    []Comment // comments outside of the AddrSpec
 }
 
 // DisplayName returns the display name of the email address or an empty string.
@@ -37,9 +35,10 @@ func (m *Mailbox) Address() string { return m.address.String() }
 // AddrSpec returns the AddrSpec used to store the email address in detail.
 func (m *Mailbox) AddrSpec() *AddrSpec { return m.address }
 
-// Comment returns the accumulated comment for the email address as a string or
-// it returns an empty string if there is no comment.
-func (m *Mailbox) Comment() string { return m.comment }
+// Comment returns the text of every comment of the email address, as returned
+// by Comments, joined with spaces. It returns an empty string if there is no
+// comment. This includes the comments found within the AddrSpec.
+func (m *Mailbox) Comment() string { return joinComments(m.Comments()) }
 
 // OriginalString either returns the originally parsed string used to create
 // this mailbox or an empty string. This is useful for roundtripping, but it
@@ -47,6 +46,12 @@ func (m *Mailbox) Comment() string { return m.comment }
 // https://tools.ietf.org/html/rfc5322#section-4
 func (m *Mailbox) OriginalString() string { return m.original }
 
+// unchanged returns true if the mailbox has an original string and neither the
+// mailbox nor its address have been modified since it was parsed.
+func (m *Mailbox) unchanged() bool {
+	return m.original != "" && m.address.original != ""
+}
+
 func checkComment(c string) error {
 	lp := 0
 
@@ -74,12 +79,23 @@ func checkComment(c string) error {
 // This will return ErrCommentUnbalancedRight or ErrCommentUnbalancedLeft if a
 // comment is given that contains mismatched parentheses.
 //
+// This will return an *UnsafeTextError if the display name or comment contains
+// a control character or bidi override (see format.IsUnsafeRune).
+//
 // On success, returns the constructed mailbox object.
 func NewMailbox(
 	displayName string,
 	addrSpec *AddrSpec,
 	comment string,
 ) (*Mailbox, error) {
+	if err := checkSafeText("display name", displayName); err != nil {
+		return nil, err
+	}
+
+	if err := checkSafeText("comment", comment); err != nil {
+		return nil, err
+	}
+
 	if err := checkComment(comment); err != nil {
 		return nil, err
 	}
@@ -87,7 +103,7 @@ func NewMailbox(
 	return &Mailbox{
 		displayName: displayName,
 		address:     addrSpec,
-		comment:     comment,
+		comments:    mailboxComments(comment),
 	}, nil
 }
 
@@ -99,6 +115,10 @@ func NewMailbox(
 // This will return ErrCommentUnbalancedRight or ErrCommentUnbalancedLeft if a
 // comment is given that contains mismatched parantheses.
 //
+// Unlike NewMailbox, unsafe characters are permitted in the display name and
+// comment as these may be found in parsed email. Use CheckSafe or StripUnsafe
+// before using such a mailbox in a new message.
+//
 // On success, returns the constructed mailbox object.
 func NewMailboxParsed(
 	displayName string,
@@ -110,7 +130,12 @@ func NewMailboxParsed(
 		return nil, err
 	}
 
-	return &Mailbox{displayName, addrSpec, comment, original}, nil
+	return &Mailbox{
+		displayName: displayName,
+		address:     addrSpec,
+		original:    original,
+		comments:    mailboxComments(comment),
+	}, nil
 }
 
 // NewMailboxStr is identical in operation to NewMailbox except that it takes
@@ -126,6 +151,9 @@ func NewMailboxParsed(
 // This will return ErrCommentUnbalancedRight or ErrCommentUnbalancedLeft if a
 // comment is given that contains mismatched parantheses.
 //
+// This will return an *UnsafeTextError if the display name or comment contains
+// a control character or bidi override.
+//
 // On success, returns the constructed mailbox object.
 func NewMailboxStr(dn string, as string, c string) (*Mailbox, error) {
 	addrs, err := ParseEmailAddrSpec(as)
@@ -145,17 +173,52 @@ func (m *Mailbox) LocalPart() string { return m.address.LocalPart() }
 func (m *Mailbox) Domain() string { return m.address.Domain() }
 
 // SetDisplayName will update the display name for the mailbox. This will also
-// clear an original string if one is set.
+// clear an original string if one is set. The display name is not checked for
+// unsafe characters; use TrySetDisplayName for that.
 func (m *Mailbox) SetDisplayName(dn string) {
 	m.displayName = dn
 	m.original = ""
 }
 
+// TrySetDisplayName works like SetDisplayName, except that if the display name
+// contains a control character or bidi override, the mailbox is left unchanged
+// and an *UnsafeTextError is returned.
+func (m *Mailbox) TrySetDisplayName(dn string) error {
+	if err := checkSafeText("display name", dn); err != nil {
+		return err
+	}
+
+	m.SetDisplayName(dn)
+	return nil
+}
+
 // SetComment will update the comment for the mailbox. This will also clear the
-// original string if one is set.
+// original string if one is set. Every comment of the mailbox is replaced by
+// the new comment, including those within the AddrSpec, which is replaced by a
+// copy without comments if it has any. Afterward, Comment returns the new
+// comment and Comments returns it as the only comment. The comment is not
+// checked for unsafe characters; use TrySetComment for that.
 func (m *Mailbox) SetComment(c string) {
-	m.comment = c
+	m.comments = mailboxComments(c)
 	m.original = ""
+	if len(m.address.comments) > 0 {
+		as := *m.address
+		as.comments = nil
+		as.original = ""
+		m.address = &as
+	}
+}
+
+// TrySetComment works like SetComment, except that if the comment contains a
+// control character or bidi override, the mailbox is left unchanged and an
+// *UnsafeTextError is returned.
+func (m *Mailbox) TrySetComment(c string) error {
+	if err := checkSafeText("comment", c); err != nil {
+		return err
+	}
+
+	m.SetComment(c)
+	return nil
 }
 
 // SetAddrSpec will update the email address for the mailbox. This will also
@@ -203,8 +266,8 @@ func (m *Mailbox) GuessName() string {
 		return m.displayName
 	}
 
-	if m.comment != "" {
-		return m.comment
+	if c := m.Comment(); c != "" {
+		return c
 	}
 
 	return m.LocalPart()
@@ -213,45 +276,18 @@ func (m *Mailbox) GuessName() string {
 // CleanString returns a proper RFC 5322 email address. If the originally parsed
 // email address was using an obsolete format, this will return the correct
 // version according to spec.
+//
+// The display name and comment are quoted, escaped, or encoded as MIME words
+// as needed (see format.RenderPhrase and format.RenderComment). As long as the
+// local part and domain are valid US-ASCII, parsing the returned string will
+// produce a mailbox with the same display name, address, and comment. Use a
+// Formatter to render the mailbox in a different style.
+//
+// Unsafe characters (see format.IsUnsafeRune) are never output. They are
+// dropped from the display name, comment, and address instead, so use
+// CheckSafe first if such a mailbox ought to be rejected.
 func (m *Mailbox) CleanString() string {
-	var a strings.Builder
-
-	// quoting can't be used when =?...?...?= mime words are in the name, use
-	// obsolete RFC822 display name instead in that case. Since we don't make
-	// any effort to understand or decode these, we assume we'll just encounter
-	// them as-is but do this one special thing for them
-	if m.displayName != "" {
-		var prepDN string
-		if format.HasMIMEWord(m.displayName) {
-			prepDN = m.displayName
-		} else if format.NeedsEncoding(m.displayName, false) {
-			// might be nice to make this configgable
-			prepDN = mime.QEncoding.Encode("utf-8", m.displayName)
-		} else {
-			prepDN = format.MaybeEscape(m.displayName, false)
-		}
-		a.WriteString(prepDN)
-
-		a.WriteString(" <")
-		a.WriteString(m.address.CleanString())
-		a.WriteString(">")
-	} else {
-		a.WriteString(m.address.CleanString())
-	}
-
-	if m.comment != "" {
-		a.WriteString(" (")
-		var prepC string
-		if format.NeedsEncoding(m.comment, true) {
-			prepC = mime.QEncoding.Encode("utf-8", m.comment)
-		} else {
-			prepC = m.comment
-		}
-		a.WriteString(prepC)
-		a.WriteString(")")
-	}
-
-	return a.String()
+	return Formatter{}.FormatMailbox(m)
 }
 
 // String is an alias for CleanString.
@@ -293,21 +329,19 @@ func (ms MailboxList) OriginalString() string {
 // CleanString returns the email addresses in the canonical RFC 5322 format
 // separated by a comma.
 func (ms MailboxList) CleanString() string {
-	var a strings.Builder
-	first := true
-	for _, m := range ms {
-		if !first {
-			a.WriteString(", ")
-		}
-		a.WriteString(m.CleanString())
-		first = false
-	}
-	return a.String()
+	return Formatter{}.FormatMailboxList(ms)
 }
 
 // String is an alias for CleanString.
 func (ms MailboxList) String() string { return ms.CleanString() }
 
+// FoldedHeader renders the mailboxes as a complete header field with the given
+// name. This works identically to AddressList.FoldedHeader, including the
+// check for unsafe characters.
+func (ms MailboxList) FoldedHeader(field string) (string, error) {
+	return ms.AddressList().FoldedHeader(field)
+}
+
 // ParseEmailMailbox parses the email mailbox string.
 //
 // It is possible for this method to return a mailbox and an error, or just a
@@ -28,22 +28,19 @@ func TestObsMboxList(t *testing.T) {
 		{
 			&Mailbox{
 				displayName: "who",
-				address:     &AddrSpec{"ok", "example.com", "ok@example.com"},
-				comment:     "",
+				address:     &AddrSpec{localPart: "ok", domain: "example.com", original: "ok@example.com"},
 				original:    "\"who\" <ok@example.com>",
 			},
 		},
 		{
 			&Mailbox{
 				displayName: "who",
-				address:     &AddrSpec{"ok", "example.com", "ok@example.com"},
-				comment:     "",
+				address:     &AddrSpec{localPart: "ok", domain: "example.com", original: "ok@example.com"},
 				original:    "\"who\" <ok@example.com>",
 			},
 			&Mailbox{
 				displayName: "",
-				address:     &AddrSpec{"another", "example.com", "another@example.com"},
-				comment:     "",
+				address:     &AddrSpec{localPart: "another", domain: "example.com", original: "another@example.com"},
 				original:    "<another@example.com>",
 			},
 		},
@@ -74,8 +71,7 @@ func TestObsAddrList(t *testing.T) {
 				mailboxList: MailboxList{
 					&Mailbox{
 						displayName: "who",
-						address:     &AddrSpec{"ok", "example.com", "ok@example.com"},
-						comment:     "",
+						address:     &AddrSpec{localPart: "ok", domain: "example.com", original: "ok@example.com"},
 						original:    "\"who\" <ok@example.com>",
 					},
 				},
@@ -88,17 +84,18 @@ func TestObsAddrList(t *testing.T) {
 				mailboxList: MailboxList{
 					&Mailbox{
 						displayName: "who",
-						address:     &AddrSpec{"ok", "example.com", "ok@example.com"},
-						comment:     "",
+						address:     &AddrSpec{localPart: "ok", domain: "example.com", original: "ok@example.com"},
 						original:    "\"who\" <ok@example.com>",
 					},
 				},
 				original: "meh: \"who\" <ok@example.com>, (obsolete comment with no address);",
+				comments: []Comment{
+					{CommentInGroupList, "obsolete comment with no address", "obsolete comment with no address"},
+				},
 			},
 			&Mailbox{
 				displayName: "",
-				address:     &AddrSpec{"another", "example.com", "another@example.com"},
-				comment:     "",
+				address:     &AddrSpec{localPart: "another", domain: "example.com", original: "another@example.com"},
 				original:    "<another@example.com>",
 			},
 		},
@@ -128,6 +125,10 @@ func TestObsGroup(t *testing.T) {
 		displayName: "meh",
 		mailboxList: MailboxList{},
 		original:    str,
+		comments: []Comment{
+			{CommentInGroupList, "obsolete comments here", "obsolete comments here"},
+			{CommentInGroupList, "obsolete comment there", "obsolete comment there"},
+		},
 	}, g)
 }
 
@@ -158,6 +159,9 @@ func TestObsDomain(t *testing.T) {
 		localPart: "okay",
 		domain:    "obs.example.com",
 		original:  str,
+		comments: []Comment{
+			{CommentInDomain, "comments!?", "comments!?"},
+		},
 	}, ml)
 }
 
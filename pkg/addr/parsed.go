@@ -0,0 +1,236 @@
+package addr
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/zostay/go-addr/pkg/rd"
+	"github.com/zostay/go-addr/pkg/rfc5322"
+)
+
+// ParsedAddressList is an address list that keeps the complete concrete syntax
+// of the text it was parsed from. Unlike AddressList, the separators, the
+// comments and whitespace between addresses, and any empty obsolete list
+// entries are all kept, so OriginalString reproduces the parsed text byte for
+// byte.
+//
+// Individual addresses may be replaced, inserted, or removed. When the list is
+// output again, only the addresses that have changed are re-rendered.
+// Everything else is output exactly as it was parsed. An address counts as
+// changed if it was put in place by Set or Insert or if it has been modified
+// since parsing (i.e., its OriginalString has been cleared by a setter).
+type ParsedAddressList struct {
+	addresses []Address
+	raws      []string // the parsed text of each address or "" if replaced
+	between   []string // the text before, between, and after the addresses
+}
+
+// listEntry locates a single address within the parsed text.
+type listEntry struct {
+	match  *rd.Match
+	offset int
+}
+
+// collectListEntries finds the match and byte offset of every address in the
+// given address list match. The offset given is where the list match starts.
+func collectListEntries(m *rd.Match, offset int) []listEntry {
+	es := make([]listEntry, 0, len(m.Submatch))
+
+	switch m.Tag {
+	case rfc5322.TAddressList:
+		for i, sm := range m.Submatch {
+			if i > 0 {
+				offset++ // skip the comma
+			}
+			es = append(es, listEntry{sm, offset})
+			offset += sm.Length()
+		}
+	case rfc5322.TObsAddrList:
+		gh := m.Group["head"]
+		offset += m.Length() - gh.Length() - m.Group["tail"].Length()
+		es = append(es, listEntry{gh, offset})
+		offset += gh.Length()
+
+		for _, sm := range m.Group["tail"].Submatch {
+			if ga := sm.Group["address"]; ga != nil {
+				if _, ok := madeAddress(ga); ok {
+					es = append(es, listEntry{ga, offset + sm.Length() - ga.Length()})
+				}
+			}
+			offset += sm.Length()
+		}
+	}
+
+	return es
+}
+
+// ParseEmailAddressListPreserving parses a list of addresses like
+// ParseEmailAddressList, but returns a ParsedAddressList that keeps the
+// complete original text. Leading and trailing whitespace is kept as well.
+//
+// If the parse partially succeeds, the list is returned along with a
+// PartialParseError. The unparsed remainder is kept as trailing text of the
+// list, so that OriginalString still reproduces the input.
+func ParseEmailAddressListPreserving(a string) (*ParsedAddressList, error) {
+	m, cs := rfc5322.MatchAddressList([]byte(a))
+
+	var addresses AddressList
+	err := ApplyActions(m, &addresses)
+	if err != nil {
+		return nil, err
+	}
+
+	pal := &ParsedAddressList{
+		addresses: make([]Address, 0, len(addresses)),
+		raws:      make([]string, 0, len(addresses)),
+		between:   make([]string, 0, len(addresses)+1),
+	}
+
+	pos := 0
+	for _, e := range collectListEntries(m, 0) {
+		address, _ := madeAddress(e.match)
+
+		content := a[e.offset : e.offset+e.match.Length()]
+		lead := len(content) - len(strings.TrimLeftFunc(content, unicode.IsSpace))
+		raw := strings.TrimSpace(content)
+		start := e.offset + lead
+
+		pal.between = append(pal.between, a[pos:start])
+		pal.addresses = append(pal.addresses, address)
+		pal.raws = append(pal.raws, raw)
+		pos = start + len(raw)
+	}
+	pal.between = append(pal.between, a[pos:])
+
+	if len(cs) > 0 {
+		return pal, PartialParseError{string(cs)}
+	}
+
+	return pal, nil
+}
+
+// Len returns the number of addresses in the list.
+func (pal *ParsedAddressList) Len() int { return len(pal.addresses) }
+
+// At returns the address at the given index. The address may be modified in
+// place, which will cause it to be re-rendered on output.
+func (pal *ParsedAddressList) At(i int) Address { return pal.addresses[i] }
+
+// Set replaces the address at the given index. The surrounding text is kept.
+func (pal *ParsedAddressList) Set(i int, a Address) {
+	pal.addresses[i] = a
+	pal.raws[i] = ""
+}
+
+// Insert adds an address so that it will be found at the given index. The
+// index may be equal to Len to append to the end of the list. A comma and a
+// space is used to separate the new address from its neighbor.
+func (pal *ParsedAddressList) Insert(i int, a Address) {
+	n := len(pal.addresses)
+
+	pal.addresses = append(pal.addresses, nil)
+	copy(pal.addresses[i+1:], pal.addresses[i:])
+	pal.addresses[i] = a
+
+	pal.raws = append(pal.raws, "")
+	copy(pal.raws[i+1:], pal.raws[i:])
+	pal.raws[i] = ""
+
+	// the new separator goes after the new address unless it is appended
+	sep, at := ", ", i+1
+	if i == n {
+		at = n
+		if n == 0 {
+			sep, at = "", 1
+		}
+	}
+
+	pal.between = append(pal.between, "")
+	copy(pal.between[at+1:], pal.between[at:])
+	pal.between[at] = sep
+}
+
+// Remove deletes the address at the given index along with one of the
+// separators next to it. The text before the first address and after the last
+// address is always kept.
+func (pal *ParsedAddressList) Remove(i int) {
+	n := len(pal.addresses)
+
+	copy(pal.addresses[i:], pal.addresses[i+1:])
+	pal.addresses = pal.addresses[:n-1]
+
+	copy(pal.raws[i:], pal.raws[i+1:])
+	pal.raws = pal.raws[:n-1]
+
+	// drop the separator following the address, or the one preceding it if
+	// this is the last address, or merge the leading and trailing text if
+	// this is the only address
+	var at int
+	switch {
+	case n == 1:
+		pal.between[0] += pal.between[1]
+		at = 1
+	case i == n-1:
+		at = i
+	default:
+		at = i + 1
+	}
+
+	copy(pal.between[at:], pal.between[at+1:])
+	pal.between = pal.between[:n]
+}
+
+// AddressList returns the addresses as an AddressList. The returned slice is
+// a copy, but the addresses within it are shared.
+func (pal *ParsedAddressList) AddressList() AddressList {
+	as := make(AddressList, len(pal.addresses))
+	copy(as, pal.addresses)
+	return as
+}
+
+// unchanged returns true if the address at the given index can be output using
+// its parsed text.
+func (pal *ParsedAddressList) unchanged(i int) bool {
+	if pal.raws[i] == "" {
+		return false
+	}
+
+	switch v := pal.addresses[i].(type) {
+	case *Group:
+		return v.unchanged()
+	case *Mailbox:
+		return v.unchanged()
+	}
+
+	return pal.addresses[i].OriginalString() != ""
+}
+
+// OriginalString returns the list as text. If nothing has been changed, this is
+// identical to the parsed text. Otherwise, changed addresses are rendered with
+// a Formatter preferring original strings and everything else is output as it
+// was parsed.
+func (pal *ParsedAddressList) OriginalString() string {
+	f := Formatter{PreferOriginal: true}
+
+	var a strings.Builder
+	for i, addr := range pal.addresses {
+		a.WriteString(pal.between[i])
+		if pal.unchanged(i) {
+			a.WriteString(pal.raws[i])
+		} else {
+			a.WriteString(f.Format(addr))
+		}
+	}
+	a.WriteString(pal.between[len(pal.addresses)])
+
+	return a.String()
+}
+
+// CleanString returns the canonical version of the addresses in the list,
+// exactly as AddressList.CleanString does.
+func (pal *ParsedAddressList) CleanString() string {
+	return pal.AddressList().CleanString()
+}
+
+// String is an alias for CleanString.
+func (pal *ParsedAddressList) String() string { return pal.CleanString() }
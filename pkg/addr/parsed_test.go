@@ -0,0 +1,150 @@
+package addr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var preservingInputs = []string{
+	"a@example.com",
+	"  a@example.com , b@example.com  ",
+	", (weird stuff), \"J.R.R. Tolkein\" <j.r.r.tolkein@example.com>, \"C.S. Lewis\" <jack@example.com>, (wacky)",
+	"Brotherhood: \"Winston Smith\" <winston.smith@recdep.minitrue> (Records Department), Julia <julia@ficdep.minitrue>;, user <user@oceania>",
+	"one@example.com,\r\n two@example.com,\r\n\tThree <three@example.com>",
+	"meh: \"who\" <ok@example.com>, (obsolete comment with no address);, <another@example.com>",
+	"Group: a@example.com; (trailing), \"a \" <b@example.com>",
+}
+
+func TestParsedAddressListRoundtrip(t *testing.T) {
+	t.Parallel()
+
+	for _, in := range preservingInputs {
+		pal, err := ParseEmailAddressListPreserving(in)
+		if assert.NoError(t, err, in) {
+			assert.Equal(t, in, pal.OriginalString())
+		}
+	}
+}
+
+func TestParsedAddressListEntries(t *testing.T) {
+	t.Parallel()
+
+	pal, err := ParseEmailAddressListPreserving(preservingInputs[2])
+	assert.NoError(t, err)
+
+	if assert.Equal(t, 2, pal.Len()) {
+		assert.Equal(t, "j.r.r.tolkein@example.com", pal.At(0).Address())
+		assert.Equal(t, "jack@example.com", pal.At(1).Address())
+	}
+
+	assert.Equal(t,
+		"\"J.R.R. Tolkein\" <j.r.r.tolkein@example.com>, \"C.S. Lewis\" <jack@example.com>",
+		pal.CleanString(),
+	)
+}
+
+func TestParsedAddressListEdit(t *testing.T) {
+	t.Parallel()
+
+	const in = "(first) A <a@example.com>,\r\n b@example.com (bee) ,  C  <c@example.com>"
+
+	pal, err := ParseEmailAddressListPreserving(in)
+	assert.NoError(t, err)
+
+	pal.At(1).(*Mailbox).SetDisplayName("Bee")
+	assert.Equal(t,
+		"(first) A <a@example.com>,\r\n Bee <b@example.com> (bee) ,  C  <c@example.com>",
+		pal.OriginalString(),
+	)
+
+	d, err := NewMailboxStr("Dee", "d@example.com", "")
+	assert.NoError(t, err)
+	pal.Set(0, d)
+	assert.Equal(t,
+		"Dee <d@example.com>,\r\n Bee <b@example.com> (bee) ,  C  <c@example.com>",
+		pal.OriginalString(),
+	)
+}
+
+func TestParsedAddressListInsertRemove(t *testing.T) {
+	t.Parallel()
+
+	const in = " a@example.com ,(x) b@example.com "
+
+	pal, err := ParseEmailAddressListPreserving(in)
+	assert.NoError(t, err)
+
+	n, err := NewMailboxStr("", "n@example.com", "")
+	assert.NoError(t, err)
+
+	pal.Insert(0, n)
+	assert.Equal(t, " n@example.com, a@example.com ,(x) b@example.com ", pal.OriginalString())
+
+	pal.Insert(3, n)
+	assert.Equal(t, " n@example.com, a@example.com ,(x) b@example.com, n@example.com ", pal.OriginalString())
+
+	pal.Insert(2, n)
+	assert.Equal(t, " n@example.com, a@example.com ,n@example.com, (x) b@example.com, n@example.com ", pal.OriginalString())
+
+	pal.Remove(4)
+	assert.Equal(t, " n@example.com, a@example.com ,n@example.com, (x) b@example.com ", pal.OriginalString())
+
+	pal.Remove(0)
+	assert.Equal(t, " a@example.com ,n@example.com, (x) b@example.com ", pal.OriginalString())
+
+	pal.Remove(1)
+	assert.Equal(t, " a@example.com ,(x) b@example.com ", pal.OriginalString())
+
+	pal.Remove(1)
+	pal.Remove(0)
+	assert.Equal(t, 0, pal.Len())
+	assert.Equal(t, "  ", pal.OriginalString())
+
+	pal.Insert(0, n)
+	assert.Equal(t, "  n@example.com", pal.OriginalString())
+}
+
+func TestParsedAddressListGroupMember(t *testing.T) {
+	t.Parallel()
+
+	const in = "Team: A <a@example.com>,  b@example.com;, c@example.com"
+
+	pal, err := ParseEmailAddressListPreserving(in)
+	assert.NoError(t, err)
+
+	g := pal.At(0).(*Group)
+	g.MailboxList()[0].SetDisplayName("Ay")
+	assert.Equal(t, "Team: Ay <a@example.com>, b@example.com;, c@example.com", pal.OriginalString())
+}
+
+func TestParsedAddressListAddrSpecEdit(t *testing.T) {
+	t.Parallel()
+
+	const in = "John <john@example.com>,  Team: A <a@example.com>,  b@example.com;"
+
+	pal, err := ParseEmailAddressListPreserving(in)
+	assert.NoError(t, err)
+
+	pal.At(0).(*Mailbox).AddrSpec().SetDomain("example.org")
+	assert.Equal(t, "John <john@example.org>,  Team: A <a@example.com>,  b@example.com;", pal.OriginalString())
+
+	pal.At(1).(*Group).MailboxList()[1].AddrSpec().SetLocalPart("bee")
+	assert.Equal(t, "John <john@example.org>,  Team: A <a@example.com>, bee@example.com;", pal.OriginalString())
+
+	mb, err := ParseEmailMailbox("Jane <jane@example.com>")
+	assert.NoError(t, err)
+	mb.AddrSpec().SetDomain("example.net")
+	assert.Equal(t, "Jane <jane@example.net>", Formatter{PreferOriginal: true}.FormatMailbox(mb))
+}
+
+func TestParsedAddressListPartial(t *testing.T) {
+	t.Parallel()
+
+	const in = "a@example.com, b@example.com and more"
+
+	pal, err := ParseEmailAddressListPreserving(in)
+	assert.IsType(t, PartialParseError{}, err)
+	assert.Equal(t, 2, pal.Len())
+	assert.Equal(t, in, pal.OriginalString())
+}
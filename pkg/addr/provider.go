@@ -0,0 +1,220 @@
+package addr
+
+import (
+	"strings"
+	"sync"
+)
+
+// ProviderRule describes how a mail provider interprets the local parts of the
+// addresses it hosts, so that addresses delivered to the same mailbox can be
+// normalized to the same AddrSpec.
+type ProviderRule struct {
+	// Name identifies the provider in the reports returned by Normalize.
+	Name string
+
+	// Domains lists every domain hosted by the provider.
+	Domains []string
+
+	// CanonicalDomain, if set, replaces any of the Domains. Only set this when
+	// the same local part reaches the same mailbox at every domain, e.g.,
+	// "googlemail.com" and "gmail.com".
+	CanonicalDomain string
+
+	// IgnoreDots is set when the provider ignores the periods in a local part.
+	IgnoreDots bool
+
+	// DetailSeparators holds the characters that separate the user from the
+	// detail of a subaddress, e.g., "+". The separator and the detail are
+	// removed. A separator at the start of the local part is left alone.
+	DetailSeparators string
+
+	// FoldCase is set when the provider ignores the case of the local part.
+	FoldCase bool
+}
+
+// These are the kinds of rule that are reported as applied by Normalize.
+const (
+	RuleDomainAlias = "domain alias"
+	RuleFoldCase    = "fold case"
+	RuleStripDetail = "strip detail"
+	RuleIgnoreDots  = "ignore dots"
+)
+
+// AppliedRule reports a single change made by ProviderRegistry.Normalize.
+type AppliedRule struct {
+	Provider string // the name of the provider whose rule was applied
+	Rule     string // the kind of rule, e.g., RuleDomainAlias
+	Before   string // the address before the rule was applied
+	After    string // the address after the rule was applied
+}
+
+// builtinProviders are the rules for well known providers. Hotmail, Live,
+// Outlook, and MSN share the same rules, but they are separate namespaces, so
+// no domain alias is given for them. See MicrosoftAliasRule.
+var builtinProviders = []ProviderRule{
+	{
+		Name:             "gmail",
+		Domains:          []string{"gmail.com", "googlemail.com"},
+		CanonicalDomain:  "gmail.com",
+		IgnoreDots:       true,
+		DetailSeparators: "+",
+		FoldCase:         true,
+	},
+	{
+		Name:             "microsoft",
+		Domains:          []string{"outlook.com", "hotmail.com", "live.com", "msn.com"},
+		DetailSeparators: "+",
+		FoldCase:         true,
+	},
+	{
+		Name:             "icloud",
+		Domains:          []string{"icloud.com", "me.com", "mac.com"},
+		CanonicalDomain:  "icloud.com",
+		DetailSeparators: "+",
+		FoldCase:         true,
+	},
+	{
+		Name:     "yahoo",
+		Domains:  []string{"yahoo.com", "ymail.com", "rocketmail.com"},
+		FoldCase: true,
+	},
+	{
+		Name:             "fastmail",
+		Domains:          []string{"fastmail.com", "fastmail.fm"},
+		DetailSeparators: "+",
+		FoldCase:         true,
+	},
+	{
+		Name:             "proton",
+		Domains:          []string{"proton.me", "protonmail.com", "protonmail.ch", "pm.me"},
+		CanonicalDomain:  "proton.me",
+		DetailSeparators: "+",
+		FoldCase:         true,
+	},
+}
+
+// MicrosoftAliasRule is an alternative to the built-in rule for Microsoft that
+// also treats Hotmail, Live, and MSN as aliases of "outlook.com".
+//
+// It is not used by default because these domains are separate namespaces:
+// "john@hotmail.com" and "john@outlook.com" may well belong to different
+// people, so aliasing them makes distinct addresses compare as the same. That
+// is wrong for deduplicating a mailing list, but may be what is wanted for
+// fraud checks, where treating the same name at each domain as one person is
+// the safer mistake. Register it with a registry to opt in:
+//
+//	r := NewProviderRegistry()
+//	r.Register(MicrosoftAliasRule)
+var MicrosoftAliasRule = ProviderRule{
+	Name:             "microsoft",
+	Domains:          []string{"outlook.com", "hotmail.com", "live.com", "msn.com"},
+	CanonicalDomain:  "outlook.com",
+	DetailSeparators: "+",
+	FoldCase:         true,
+}
+
+// ProviderRegistry holds the rules for a set of mail providers, looked up by
+// domain. It is safe for concurrent use.
+type ProviderRegistry struct {
+	lock     sync.RWMutex
+	byDomain map[string]ProviderRule
+}
+
+// DefaultProviders is the registry used by NormalizeProvider. It starts with the
+// built-in rules for well known providers. Rules may be added or replaced at
+// any time using Register.
+var DefaultProviders = NewProviderRegistry(builtinProviders...)
+
+// NewProviderRegistry returns a registry holding the given rules.
+func NewProviderRegistry(rules ...ProviderRule) *ProviderRegistry {
+	r := &ProviderRegistry{byDomain: map[string]ProviderRule{}}
+	for _, rule := range rules {
+		r.Register(rule)
+	}
+	return r
+}
+
+// Register adds the rule to the registry. It replaces the rule of any provider
+// previously registered for the same domains.
+func (r *ProviderRegistry) Register(rule ProviderRule) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for _, d := range rule.Domains {
+		r.byDomain[domainKey(d)] = rule
+	}
+}
+
+// Lookup returns the rule for the provider hosting the given domain.
+func (r *ProviderRegistry) Lookup(domain string) (ProviderRule, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	rule, ok := r.byDomain[domainKey(domain)]
+	return rule, ok
+}
+
+// Normalize returns the address as normalized by the rules of its provider
+// along with a report of each rule that changed it. The local part and domain
+// are first put in canonical form (see canonicalParts). If no provider is
+// registered for the domain, only that is done and no rules are reported.
+func (r *ProviderRegistry) Normalize(as *AddrSpec) (*AddrSpec, []AppliedRule) {
+	lp, d := canonicalParts(as)
+
+	rule, ok := r.Lookup(d)
+	if !ok {
+		return NewAddrSpec(lp, d), nil
+	}
+
+	var applied []AppliedRule
+	apply := func(kind, nlp, nd string) {
+		if nlp == lp && nd == d {
+			return
+		}
+
+		applied = append(applied, AppliedRule{
+			Provider: rule.Name,
+			Rule:     kind,
+			Before:   NewAddrSpec(lp, d).CleanString(),
+			After:    NewAddrSpec(nlp, nd).CleanString(),
+		})
+		lp, d = nlp, nd
+	}
+
+	if rule.CanonicalDomain != "" {
+		apply(RuleDomainAlias, lp, domainKey(rule.CanonicalDomain))
+	}
+
+	if rule.FoldCase {
+		apply(RuleFoldCase, strings.ToLower(lp), d)
+	}
+
+	if rule.DetailSeparators != "" {
+		// a separator at the start does not begin a detail, as there would
+		// be no user left
+		if i := strings.IndexAny(lp, rule.DetailSeparators); i > 0 {
+			apply(RuleStripDetail, lp[:i], d)
+		}
+	}
+
+	if rule.IgnoreDots {
+		apply(RuleIgnoreDots, strings.ReplaceAll(lp, ".", ""), d)
+	}
+
+	return NewAddrSpec(lp, d), applied
+}
+
+// KeyFunc returns a KeyFunc for use with an AddressSet that treats mailboxes as
+// the same when they normalize to the same address.
+func (r *ProviderRegistry) KeyFunc() KeyFunc {
+	return func(mb *Mailbox) string {
+		as, _ := r.Normalize(mb.AddrSpec())
+		return as.CleanString()
+	}
+}
+
+// NormalizeProvider normalizes the address using DefaultProviders. See
+// ProviderRegistry.Normalize.
+func NormalizeProvider(as *AddrSpec) (*AddrSpec, []AppliedRule) {
+	return DefaultProviders.Normalize(as)
+}
@@ -0,0 +1,107 @@
+package addr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeProvider(t *testing.T) {
+	t.Parallel()
+
+	as, err := ParseEmailAddrSpec("J.o.h.n+promo@googlemail.com")
+	assert.NoError(t, err)
+
+	n, applied := NormalizeProvider(as)
+	assert.Equal(t, "john@gmail.com", n.CleanString())
+	assert.Equal(t, []AppliedRule{
+		{"gmail", RuleDomainAlias, "J.o.h.n+promo@googlemail.com", "J.o.h.n+promo@gmail.com"},
+		{"gmail", RuleFoldCase, "J.o.h.n+promo@gmail.com", "j.o.h.n+promo@gmail.com"},
+		{"gmail", RuleStripDetail, "j.o.h.n+promo@gmail.com", "j.o.h.n@gmail.com"},
+		{"gmail", RuleIgnoreDots, "j.o.h.n@gmail.com", "john@gmail.com"},
+	}, applied)
+
+	tests := []struct {
+		in, out string
+		rules   int
+	}{
+		{"john@gmail.com", "john@gmail.com", 0},
+		{"John+x@Hotmail.com", "john@hotmail.com", 2},
+		{"j.ohn@outlook.com", "j.ohn@outlook.com", 0},
+		{"john+x@me.com", "john@icloud.com", 2},
+		{"John.Smith+x@Example.COM", "John.Smith+x@example.com", 0},
+		{"+promo@gmail.com", "+promo@gmail.com", 0},
+		{"+Promo+x@Hotmail.com", "+promo+x@hotmail.com", 1},
+	}
+
+	for _, tc := range tests {
+		as, err := ParseEmailAddrSpec(tc.in)
+		assert.NoError(t, err, tc.in)
+
+		n, applied := NormalizeProvider(as)
+		assert.Equal(t, tc.out, n.CleanString(), tc.in)
+		assert.Len(t, applied, tc.rules, tc.in)
+	}
+}
+
+func TestProviderRegistry(t *testing.T) {
+	t.Parallel()
+
+	r := NewProviderRegistry(ProviderRule{
+		Name:             "corp",
+		Domains:          []string{"corp.example", "Old-Corp.example"},
+		CanonicalDomain:  "corp.example",
+		DetailSeparators: "-+",
+	})
+
+	rule, ok := r.Lookup("old-corp.EXAMPLE")
+	assert.True(t, ok)
+	assert.Equal(t, "corp", rule.Name)
+
+	_, ok = r.Lookup("gmail.com")
+	assert.False(t, ok)
+
+	n, applied := r.Normalize(NewAddrSpec("Sales-Leads", "old-corp.example"))
+	assert.Equal(t, "Sales@corp.example", n.CleanString())
+	assert.Len(t, applied, 2)
+
+	r.Register(ProviderRule{Name: "old", Domains: []string{"old-corp.example"}})
+	n, applied = r.Normalize(NewAddrSpec("Sales-Leads", "old-corp.example"))
+	assert.Equal(t, "Sales-Leads@old-corp.example", n.CleanString())
+	assert.Empty(t, applied)
+
+	s := NewAddressSet(DefaultProviders.KeyFunc(), FlattenGroups)
+	s.AddList(mustParseList(t, "john@gmail.com, J.ohn+news@googlemail.com, jane@gmail.com"))
+	assert.Equal(t, 2, s.Len())
+
+	s.AddList(mustParseList(t, "+a@gmail.com, +b@gmail.com"))
+	assert.Equal(t, 4, s.Len())
+}
+
+func TestMicrosoftAliasRule(t *testing.T) {
+	t.Parallel()
+
+	as := NewAddrSpec("John+x", "Hotmail.com")
+
+	n, _ := NormalizeProvider(as)
+	assert.Equal(t, "john@hotmail.com", n.CleanString())
+
+	r := NewProviderRegistry(builtinProviders...)
+	r.Register(MicrosoftAliasRule)
+
+	n, applied := r.Normalize(as)
+	assert.Equal(t, "john@outlook.com", n.CleanString())
+	assert.Equal(t, []AppliedRule{
+		{"microsoft", RuleDomainAlias, "John+x@hotmail.com", "John+x@outlook.com"},
+		{"microsoft", RuleFoldCase, "John+x@outlook.com", "john+x@outlook.com"},
+		{"microsoft", RuleStripDetail, "john+x@outlook.com", "john@outlook.com"},
+	}, applied)
+
+	for _, d := range []string{"live.com", "msn.com", "outlook.com"} {
+		n, _ = r.Normalize(NewAddrSpec("john", d))
+		assert.Equal(t, "john@outlook.com", n.CleanString(), d)
+	}
+
+	n, _ = r.Normalize(NewAddrSpec("john", "googlemail.com"))
+	assert.Equal(t, "john@gmail.com", n.CleanString())
+}
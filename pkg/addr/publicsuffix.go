@@ -0,0 +1,215 @@
+package addr
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// PublicSuffixList looks up the public suffix of a domain, i.e., the part of
+// the domain under which anyone may register a name, such as "com" or "co.uk".
+type PublicSuffixList interface {
+	// PublicSuffix returns the public suffix of the domain, which is given in
+	// lowercase ASCII form. If no rule of the list matches, the last label of
+	// the domain is returned along with false.
+	PublicSuffix(domain string) (suffix string, listed bool)
+}
+
+// builtinSuffixList is the Public Suffix List compiled into the
+// golang.org/x/net/publicsuffix package.
+type builtinSuffixList struct{}
+
+// PublicSuffix returns the public suffix of the domain.
+func (builtinSuffixList) PublicSuffix(domain string) (string, bool) {
+	s, icann := publicsuffix.PublicSuffix(domain)
+
+	// private rules always have more than one label, so a suffix with a single
+	// label that is not from the ICANN section came from the implicit "*" rule
+	return s, icann || strings.Contains(s, ".")
+}
+
+// BuiltinPublicSuffixList is the copy of the Public Suffix List built into the
+// library.
+var BuiltinPublicSuffixList PublicSuffixList = builtinSuffixList{}
+
+var (
+	defaultPSLLock          sync.RWMutex
+	defaultPublicSuffixList = BuiltinPublicSuffixList
+)
+
+// SetDefaultPublicSuffixList replaces the list used by RegistrableDomain and
+// the other public suffix methods, e.g., with a more recent copy loaded by
+// LoadPublicSuffixList. Passing nil restores BuiltinPublicSuffixList. It is
+// safe to call while those methods are in use.
+func SetDefaultPublicSuffixList(l PublicSuffixList) {
+	if l == nil {
+		l = BuiltinPublicSuffixList
+	}
+
+	defaultPSLLock.Lock()
+	defer defaultPSLLock.Unlock()
+
+	defaultPublicSuffixList = l
+}
+
+// defaultPSL returns the list set by SetDefaultPublicSuffixList.
+func defaultPSL() PublicSuffixList {
+	defaultPSLLock.RLock()
+	defer defaultPSLLock.RUnlock()
+
+	return defaultPublicSuffixList
+}
+
+// These are the kinds of rule in a Public Suffix List. A list may have more
+// than one kind of rule for the same name, e.g., both "ck" and "*.ck", so the
+// kinds are bits.
+const (
+	suffixRule = 1 << iota
+	suffixWildcard
+	suffixException
+)
+
+// SuffixList is a Public Suffix List read from a file in the format published
+// at https://publicsuffix.org/list/.
+type SuffixList struct {
+	rules map[string]int // the kinds of rule for each name
+}
+
+// ParsePublicSuffixList reads a Public Suffix List. Rules are converted to
+// lowercase ASCII form. An error is returned if the list cannot be read or
+// contains a rule that is not a valid domain.
+func ParsePublicSuffixList(r io.Reader) (*SuffixList, error) {
+	l := &SuffixList{rules: map[string]int{}}
+
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		fs := strings.Fields(s.Text())
+		if len(fs) == 0 || strings.HasPrefix(fs[0], "//") {
+			continue
+		}
+
+		rule, kind := fs[0], suffixRule
+		switch {
+		case strings.HasPrefix(rule, "!"):
+			rule, kind = rule[1:], suffixException
+		case strings.HasPrefix(rule, "*."):
+			rule, kind = rule[2:], suffixWildcard
+		}
+
+		ar, err := canonicalDomain(rule)
+		if err != nil {
+			return nil, fmt.Errorf("public suffix list line %d: %w", n, err)
+		}
+
+		l.rules[ar] |= kind
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// LoadPublicSuffixList reads a Public Suffix List from the named file. See
+// ParsePublicSuffixList.
+func LoadPublicSuffixList(path string) (*SuffixList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParsePublicSuffixList(f)
+}
+
+// PublicSuffix returns the public suffix of the domain using the algorithm
+// described at https://publicsuffix.org/list/.
+func (l *SuffixList) PublicSuffix(domain string) (string, bool) {
+	labels := strings.Split(domain, ".")
+	for i := range labels {
+		cand := strings.Join(labels[i:], ".")
+		kinds := l.rules[cand]
+		if kinds&suffixException != 0 {
+			return strings.Join(labels[i+1:], "."), true
+		} else if kinds&suffixRule != 0 {
+			return cand, true
+		}
+
+		if i+1 < len(labels) && l.rules[strings.Join(labels[i+1:], ".")]&suffixWildcard != 0 {
+			return cand, true
+		}
+	}
+
+	return labels[len(labels)-1], false
+}
+
+// publicSuffix returns the public suffix of the domain, which is put into
+// canonical form first, using the default list. Domain literals have no
+// public suffix.
+func publicSuffix(d string) (string, string, bool) {
+	if strings.HasPrefix(d, "[") {
+		return d, "", false
+	}
+
+	d = strings.TrimSuffix(domainKey(d), ".")
+	s, listed := defaultPSL().PublicSuffix(d)
+	return d, s, listed
+}
+
+// registrableDomain returns the registrable domain of the domain, i.e., the
+// public suffix and the label before it, or the empty string if the domain is
+// a domain literal or a public suffix itself.
+func registrableDomain(d string) string {
+	d, s, _ := publicSuffix(d)
+	if s == "" || d == s || !strings.HasSuffix(d, "."+s) {
+		return ""
+	}
+
+	rest := d[:len(d)-len(s)-1]
+	return rest[strings.LastIndex(rest, ".")+1:] + "." + s
+}
+
+// PublicSuffix returns the public suffix of the domain, e.g., "co.uk" for
+// "mail.corp.example.co.uk", in lowercase ASCII form, according to the list
+// set by SetDefaultPublicSuffixList. If the domain does not end with a listed
+// suffix, its last label is returned. Domain literals return the empty string.
+func (as *AddrSpec) PublicSuffix() string {
+	_, s, _ := publicSuffix(as.domain)
+	return s
+}
+
+// RegistrableDomain returns the registrable domain, also called the eTLD+1, of
+// the domain, e.g., "example.co.uk" for "mail.corp.example.co.uk", in
+// lowercase ASCII form. This is the domain registered by the organization that
+// owns the address. The empty string is returned for domain literals and for
+// domains that are a public suffix themselves.
+func (as *AddrSpec) RegistrableDomain() string {
+	return registrableDomain(as.domain)
+}
+
+// IsPublicSuffix returns true if the domain is itself a public suffix, such as
+// "co.uk", so that no organization owns the address.
+func (as *AddrSpec) IsPublicSuffix() bool {
+	d, s, listed := publicSuffix(as.domain)
+	return listed && d == s
+}
+
+// HasKnownTLD returns true if the top-level domain of the domain appears in the
+// list set by SetDefaultPublicSuffixList. It returns false for domain literals.
+func (as *AddrSpec) HasKnownTLD() bool {
+	d, s, _ := publicSuffix(as.domain)
+	if s == "" {
+		return false
+	}
+
+	// a name is looked up under the TLD so that TLDs with only a wildcard rule,
+	// such as "*.ck", are found
+	_, listed := defaultPSL().PublicSuffix("x." + d[strings.LastIndex(d, ".")+1:])
+	return listed
+}
@@ -0,0 +1,174 @@
+package addr
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSuffixList = `// ===BEGIN ICANN DOMAINS===
+com
+uk
+co.uk
+
+// wildcard with an exception
+*.ck
+!www.ck
+
+// an internationalized rule
+рф
+// ===END ICANN DOMAINS===
+
+// ===BEGIN PRIVATE DOMAINS===
+blogspot.com
+// ===END PRIVATE DOMAINS===
+`
+
+func TestSuffixList(t *testing.T) {
+	t.Parallel()
+
+	l, err := ParsePublicSuffixList(strings.NewReader(testSuffixList))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tests := []struct {
+		domain, suffix string
+		listed         bool
+	}{
+		{"example.com", "com", true},
+		{"com", "com", true},
+		{"mail.corp.example.co.uk", "co.uk", true},
+		{"example.uk", "uk", true},
+		{"foo.ck", "foo.ck", true},
+		{"a.foo.ck", "foo.ck", true},
+		{"www.ck", "ck", true},
+		{"a.www.ck", "ck", true},
+		{"example.xn--p1ai", "xn--p1ai", true},
+		{"me.blogspot.com", "blogspot.com", true},
+		{"example.unknown", "unknown", false},
+	}
+
+	for _, tc := range tests {
+		s, listed := l.PublicSuffix(tc.domain)
+		assert.Equal(t, tc.suffix, s, tc.domain)
+		assert.Equal(t, tc.listed, listed, tc.domain)
+	}
+
+	_, err = ParsePublicSuffixList(strings.NewReader("com\nxn--a.example\n"))
+	if assert.Error(t, err) {
+		assert.True(t, strings.HasPrefix(err.Error(), "public suffix list line 2: "))
+	}
+}
+
+func TestSuffixListSameName(t *testing.T) {
+	t.Parallel()
+
+	l, err := ParsePublicSuffixList(strings.NewReader("foo\n*.foo\n*.bar\nbar\n!www.bar\n"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tests := []struct {
+		domain, suffix string
+	}{
+		{"foo", "foo"},
+		{"example.foo", "example.foo"},
+		{"a.example.foo", "example.foo"},
+		{"bar", "bar"},
+		{"example.bar", "example.bar"},
+		{"a.example.bar", "example.bar"},
+		{"www.bar", "bar"},
+		{"a.www.bar", "bar"},
+	}
+
+	for _, tc := range tests {
+		s, listed := l.PublicSuffix(tc.domain)
+		assert.Equal(t, tc.suffix, s, tc.domain)
+		assert.True(t, listed, tc.domain)
+	}
+}
+
+func TestLoadPublicSuffixList(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "psl")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "public_suffix_list.dat")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(testSuffixList), 0644))
+
+	l, err := LoadPublicSuffixList(path)
+	assert.NoError(t, err)
+	s, _ := l.PublicSuffix("example.co.uk")
+	assert.Equal(t, "co.uk", s)
+
+	_, err = LoadPublicSuffixList(filepath.Join(dir, "missing.dat"))
+	assert.Error(t, err)
+}
+
+func TestRegistrableDomain(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		domain, suffix, regdom string
+		isSuffix, knownTLD     bool
+	}{
+		{"mail.corp.example.co.uk", "co.uk", "example.co.uk", false, true},
+		{"Example.COM", "com", "example.com", false, true},
+		{"bücher.example.de", "de", "example.de", false, true},
+		{"co.uk", "co.uk", "", true, true},
+		{"me.blogspot.com", "blogspot.com", "me.blogspot.com", false, true},
+		{"example.unknowntld", "unknowntld", "example.unknowntld", false, false},
+		{"foo.ck", "foo.ck", "", true, true},
+		{"[192.0.2.1]", "", "", false, false},
+	}
+
+	for _, tc := range tests {
+		as := NewAddrSpec("john", tc.domain)
+		assert.Equal(t, tc.suffix, as.PublicSuffix(), tc.domain)
+		assert.Equal(t, tc.regdom, as.RegistrableDomain(), tc.domain)
+		assert.Equal(t, tc.isSuffix, as.IsPublicSuffix(), tc.domain)
+		assert.Equal(t, tc.knownTLD, as.HasKnownTLD(), tc.domain)
+	}
+}
+
+// TestSetDefaultPublicSuffixList is not run in parallel as it replaces the
+// default list used by the other tests.
+func TestSetDefaultPublicSuffixList(t *testing.T) {
+	l, err := ParsePublicSuffixList(strings.NewReader("com\nexample.com\n"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	as := NewAddrSpec("john", "mail.corp.example.com")
+	assert.Equal(t, "example.com", as.RegistrableDomain())
+
+	SetDefaultPublicSuffixList(l)
+	defer SetDefaultPublicSuffixList(nil)
+	assert.Equal(t, "corp.example.com", as.RegistrableDomain())
+	assert.False(t, NewAddrSpec("john", "example.net").HasKnownTLD())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			_ = as.RegistrableDomain()
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		SetDefaultPublicSuffixList(l)
+	}
+	<-done
+
+	SetDefaultPublicSuffixList(nil)
+	assert.Equal(t, "example.com", as.RegistrableDomain())
+	assert.True(t, NewAddrSpec("john", "example.net").HasKnownTLD())
+}
@@ -0,0 +1,357 @@
+package addr
+
+import (
+	"strings"
+
+	"github.com/zostay/go-addr/pkg/rd"
+	p "github.com/zostay/go-addr/pkg/rfc5322"
+)
+
+// RepairFix names a fix applied by SuggestRepairs.
+type RepairFix string
+
+// These are the fixes SuggestRepairs may apply.
+const (
+	RepairCommaInDomain    RepairFix = "replace comma with period"
+	RepairRepeatedDot      RepairFix = "remove repeated period"
+	RepairRepeatedAt       RepairFix = "remove repeated at sign"
+	RepairSpelledAt        RepairFix = "replace spelled out at sign"
+	RepairSpelledDot       RepairFix = "replace spelled out period"
+	RepairWhitespace       RepairFix = "remove whitespace"
+	RepairCloseAngle       RepairFix = "add closing angle bracket"
+	RepairOpenAngle        RepairFix = "add opening angle bracket"
+	RepairAngleAddr        RepairFix = "enclose address in angle brackets"
+	RepairCloseQuote       RepairFix = "add closing quotation mark"
+	RepairQuoteDisplayName RepairFix = "quote display name"
+)
+
+// maxRepairFixes is the largest number of fixes combined in one suggestion.
+const maxRepairFixes = 3
+
+// Repair is a corrected form of an input that could not be parsed.
+type Repair struct {
+	Text    string      // the corrected input
+	Fixes   []RepairFix // the fixes applied, in order
+	Mailbox *Mailbox    // the mailbox parsed from the corrected input
+}
+
+// repairStep is the result of applying a single fix.
+type repairStep struct {
+	text string
+	fix  RepairFix
+}
+
+// isSpace returns true if the byte is whitespace.
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+// consumed returns the length of the input matched by the matcher or -1 if
+// nothing was matched.
+func consumed(match func([]byte) (*rd.Match, []byte), s string) int {
+	m, rest := match([]byte(s))
+	if m == nil {
+		return -1
+	}
+	return len(s) - len(rest)
+}
+
+// addrSpecReach parses as much of an addr-spec as possible starting at i. It
+// returns the offset at which the local part ended, which is where the "@" is
+// expected, or -1 if there is no local part, and the furthest offset reached.
+func addrSpecReach(s string, i int) (at, end int) {
+	n := consumed(p.MatchLocalPart, s[i:])
+	if n < 0 {
+		return -1, i
+	}
+
+	at = i + n
+	if at >= len(s) || s[at] != '@' {
+		return at, at
+	}
+
+	n = consumed(p.MatchDomain, s[at+1:])
+	if n < 0 {
+		return at, at + 1
+	}
+	return at, at + 1 + n
+}
+
+// replaceAt returns s with n bytes at offset i replaced by r.
+func replaceAt(s string, i, n int, r string) string {
+	return s[:i] + r + s[i+n:]
+}
+
+// spelled returns the offset and length of a spelled out word, such as "(at)",
+// "[at]", or " at ", at or just before offset i, including any whitespace
+// around it.
+func spelled(s string, i int, word string) (int, int, bool) {
+	for _, f := range []string{"(" + word + ")", "[" + word + "]", " " + word + " "} {
+		for _, j := range []int{i, i - 1, i - len(f)} {
+			if j >= 0 && j+len(f) <= len(s) && strings.EqualFold(s[j:j+len(f)], f) {
+				k := j + len(f)
+				for j > 0 && isSpace(s[j-1]) {
+					j--
+				}
+				for k < len(s) && isSpace(s[k]) {
+					k++
+				}
+				return j, k - j, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// collapseDots returns s with the run of periods around offset i replaced by a
+// single period.
+func collapseDots(s string, i int) (string, bool) {
+	j, k := i, i
+	for j > 0 && s[j-1] == '.' {
+		j--
+	}
+	for k < len(s) && s[k] == '.' {
+		k++
+	}
+
+	if k-j < 2 {
+		return s, false
+	}
+	return replaceAt(s, j, k-j, "."), true
+}
+
+// removeSpaceBefore returns s with the whitespace before offset i removed.
+func removeSpaceBefore(s string, i int) (string, bool) {
+	j := i
+	for j > 0 && isSpace(s[j-1]) {
+		j--
+	}
+
+	if j == i {
+		return s, false
+	}
+	return s[:j] + s[i:], true
+}
+
+// quoteText returns the text as a quoted string.
+func quoteText(t string) string {
+	t = strings.ReplaceAll(t, `\`, `\\`)
+	t = strings.ReplaceAll(t, `"`, `\"`)
+	return `"` + t + `"`
+}
+
+// addrSpecRepairs returns the fixes for a failure at offset i of an addr-spec,
+// where at is the offset where the "@" was expected.
+func addrSpecRepairs(s string, at, i int) []repairStep {
+	var steps []repairStep
+	add := func(t string, fix RepairFix) {
+		steps = append(steps, repairStep{t, fix})
+	}
+
+	if at >= 0 && at < len(s) && s[at] != '@' {
+		if j, n, ok := spelled(s, at, "at"); ok {
+			add(replaceAt(s, j, n, "@"), RepairSpelledAt)
+		}
+		if j := strings.Index(strings.ToLower(s[:at]), "(at)"); j >= 0 {
+			add(strings.TrimSpace(s[:j])+"@"+strings.TrimSpace(s[j+4:]), RepairSpelledAt)
+		}
+	}
+
+	if i >= len(s) {
+		return steps
+	}
+
+	switch {
+	case s[i] == ',' && i+1 < len(s) && !isSpace(s[i+1]):
+		add(replaceAt(s, i, 1, "."), RepairCommaInDomain)
+	case s[i] == '.' || (i > 0 && s[i-1] == '.'):
+		if t, ok := collapseDots(s, i); ok {
+			add(t, RepairRepeatedDot)
+		}
+	case s[i] == '@' && i > 0 && s[i-1] == '@':
+		add(replaceAt(s, i, 1, ""), RepairRepeatedAt)
+	}
+
+	if j, n, ok := spelled(s, i, "dot"); ok {
+		add(replaceAt(s, j, n, "."), RepairSpelledDot)
+	}
+
+	// whitespace is only removed from the domain and only when nothing else
+	// explains the failure, since it is also how words are separated
+	if len(steps) == 0 && at >= 0 && at < i && s[at] == '@' {
+		if t, ok := removeSpaceBefore(s, i); ok {
+			add(t, RepairWhitespace)
+		}
+	}
+
+	return steps
+}
+
+// repairSteps returns every fix suggested by where the parser fails on s.
+func repairSteps(s string) []repairStep {
+	var steps []repairStep
+	add := func(t string, fix RepairFix) {
+		steps = append(steps, repairStep{t, fix})
+	}
+
+	// the parser got partway through a mailbox
+	if n := consumed(p.MatchMailbox, s); n >= 0 {
+		if n < len(s) && s[n] == '>' && !strings.Contains(s, "<") {
+			add("<"+s, RepairOpenAngle)
+		}
+
+		at, _ := addrSpecReach(s, 0)
+		steps = append(steps, addrSpecRepairs(s, at, n)...)
+		return steps
+	}
+
+	// as an addr-spec
+	at, end := addrSpecReach(s, 0)
+	steps = append(steps, addrSpecRepairs(s, at, end)...)
+
+	// as a name-addr
+	n := consumed(p.MatchDisplayName, s)
+	if n < 0 {
+		n = 0
+	}
+
+	j := n
+	for j < len(s) && isSpace(s[j]) {
+		j++
+	}
+
+	lt := strings.Index(s[j:], "<")
+	if lt >= 0 {
+		lt += j
+	}
+
+	switch {
+	case j < len(s) && s[j] == '<':
+		_, end := addrSpecReach(s, j+1)
+		k := end
+		for k < len(s) && isSpace(s[k]) {
+			k++
+		}
+
+		if k == len(s) {
+			add(strings.TrimRight(s, " \t\r\n")+">", RepairCloseAngle)
+		} else {
+			steps = append(steps, addrSpecRepairs(s, -1, end)...)
+		}
+	case j < len(s) && s[j] == '@' && n > 0:
+		k := strings.LastIndexAny(s[:j], " \t") + 1
+		add(s[:k]+"<"+s[k:]+">", RepairAngleAddr)
+	case n == 0 && strings.HasPrefix(s, `"`) && lt > 0:
+		add(strings.TrimRight(s[:lt], " \t")+`" `+s[lt:], RepairCloseQuote)
+	case lt > 0:
+		add(quoteText(strings.TrimSpace(s[:lt]))+" "+s[lt:], RepairQuoteDisplayName)
+	}
+
+	return steps
+}
+
+// parseClean returns the mailbox if s parses as a mailbox without anything left
+// over and without whitespace inside the addr-spec.
+func parseClean(s string) *Mailbox {
+	mb, ds := DiagnoseEmailMailbox(s)
+	if mb == nil || ds.Has(CodeTrailingText) || ds.Has(CodeFWS) {
+		return nil
+	}
+	return mb
+}
+
+// SuggestRepairs proposes corrected forms of an input that does not parse
+// cleanly as a mailbox, e.g., "john@example,com" or "<john@example.com". Input
+// that parses, but has whitespace within the address, as in
+// "john @ example.com", is also repaired.
+//
+// The fixes tried depend on where the parser stopped: the offset reached while
+// parsing a mailbox, an addr-spec, or a display name and angle address. Up to
+// three fixes may be combined. Every suggestion returned parses cleanly. The
+// suggestions requiring the fewest fixes are first, and each distinct mailbox
+// is only suggested once. No suggestions are
+// returned for input that already parses cleanly or that cannot be repaired.
+func SuggestRepairs(s string) []Repair {
+	s = strings.TrimSpace(s)
+	if parseClean(s) != nil {
+		return nil
+	}
+
+	type state struct {
+		text  string
+		fixes []RepairFix
+	}
+
+	var repairs []Repair
+	found := map[string]bool{}
+	seen := map[string]bool{s: true}
+	queue := []state{{s, nil}}
+	for len(queue) > 0 {
+		st := queue[0]
+		queue = queue[1:]
+
+		steps := repairSteps(st.text)
+		if _, ds := DiagnoseEmailMailbox(st.text); ds.Has(CodeFWS) && !ds.Has(CodeTrailingText) {
+			steps = append(steps, repairStep{removeAddrSpecSpace(st.text), RepairWhitespace})
+		}
+
+		for _, step := range steps {
+			t := strings.TrimSpace(step.text)
+			if seen[t] {
+				continue
+			}
+			seen[t] = true
+
+			fixes := append(append([]RepairFix{}, st.fixes...), step.fix)
+			if mb := parseClean(t); mb != nil {
+				// a longer route to a mailbox already found is not interesting
+				if k := mb.KeyFor(EqualFull); !found[k] {
+					found[k] = true
+					repairs = append(repairs, Repair{t, fixes, mb})
+				}
+			} else if len(fixes) < maxRepairFixes {
+				queue = append(queue, state{t, fixes})
+			}
+		}
+	}
+
+	return repairs
+}
+
+// removeAddrSpecSpace returns s with the whitespace around each "@" and "."
+// outside of quoted strings and comments removed. If there is an angle
+// address, only the part within it is changed.
+func removeAddrSpecSpace(s string) string {
+	if i := strings.LastIndex(s, "<"); i >= 0 {
+		return s[:i+1] + removeAddrSpecSpace(s[i+1:])
+	}
+
+	var b strings.Builder
+	quoted, depth := false, 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && (quoted || depth > 0) && i+1 < len(s):
+			b.WriteByte(c)
+			i++
+			c = s[i]
+		case c == '"' && depth == 0:
+			quoted = !quoted
+		case c == '(' && !quoted:
+			depth++
+		case c == ')' && !quoted && depth > 0:
+			depth--
+		case (c == '@' || c == '.') && !quoted && depth == 0:
+			t := strings.TrimRight(b.String(), " \t")
+			b.Reset()
+			b.WriteString(t)
+			b.WriteByte(c)
+			for i+1 < len(s) && isSpace(s[i+1]) {
+				i++
+			}
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,56 @@
+package addr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuggestRepairs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in    string
+		out   string
+		fixes []RepairFix
+	}{
+		{"john@example,com", "john@example.com", []RepairFix{RepairCommaInDomain}},
+		{"john @ example.com", "john@example.com", []RepairFix{RepairWhitespace}},
+		{"john@@example.com", "john@example.com", []RepairFix{RepairRepeatedAt}},
+		{"<john@example.com", "<john@example.com>", []RepairFix{RepairCloseAngle}},
+		{`"John <john@example.com>`, `"John" <john@example.com>`, []RepairFix{RepairCloseQuote}},
+		{"john(at)example.com", "john@example.com", []RepairFix{RepairSpelledAt}},
+		{"john [at] example.com", "john@example.com", []RepairFix{RepairSpelledAt}},
+		{"john@example..com", "john@example.com", []RepairFix{RepairRepeatedDot}},
+		{"john..doe@example.com", "john.doe@example.com", []RepairFix{RepairRepeatedDot}},
+		{"john@example.com>", "<john@example.com>", []RepairFix{RepairOpenAngle}},
+		{"john@exa mple.com", "john@example.com", []RepairFix{RepairWhitespace}},
+		{"John Smith john@example.com", "John Smith <john@example.com>", []RepairFix{RepairAngleAddr}},
+		{"John, Smith <john@example.com>", `"John, Smith" <john@example.com>`, []RepairFix{RepairQuoteDisplayName}},
+		{"John <john @ example.com>", "John <john@example.com>", []RepairFix{RepairWhitespace}},
+		{"john at example dot com", "john@example.com", []RepairFix{RepairSpelledAt, RepairSpelledDot}},
+	}
+
+	for _, tc := range tests {
+		rs := SuggestRepairs(tc.in)
+		if !assert.Len(t, rs, 1, tc.in) {
+			continue
+		}
+
+		assert.Equal(t, tc.out, rs[0].Text, tc.in)
+		assert.Equal(t, tc.fixes, rs[0].Fixes, tc.in)
+
+		mb, err := ParseEmailMailbox(rs[0].Text)
+		assert.NoError(t, err, tc.in)
+		assert.True(t, mb.Equal(rs[0].Mailbox, EqualFull), tc.in)
+	}
+}
+
+func TestSuggestRepairsNone(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, SuggestRepairs("john@example.com"))
+	assert.Empty(t, SuggestRepairs("John Smith <john@example.com>"))
+	assert.Empty(t, SuggestRepairs("@@@"))
+	assert.Empty(t, SuggestRepairs(""))
+}
@@ -0,0 +1,214 @@
+package addr
+
+import "errors"
+
+// ReplyMode selects the recipients of a reply.
+type ReplyMode int
+
+// These are the reply modes.
+const (
+	// ReplyAuthor replies only to the author of the message.
+	ReplyAuthor ReplyMode = iota
+
+	// ReplyAll replies to the author and every other recipient of the
+	// message.
+	ReplyAll
+
+	// ReplyList replies only to the mailing list the message was sent to.
+	ReplyList
+)
+
+// String returns the name of the mode.
+func (m ReplyMode) String() string {
+	switch m {
+	case ReplyAuthor:
+		return "reply"
+	case ReplyAll:
+		return "reply all"
+	case ReplyList:
+		return "reply to list"
+	default:
+		return "unknown"
+	}
+}
+
+// ErrNoListAddress is returned when replying to a list if the message has
+// neither a Mail-Followup-To field nor a list address.
+var ErrNoListAddress = errors.New("no mailing list address to reply to")
+
+// ReplyHeaders holds the fields of an incoming message used to compute the
+// recipients of a reply. Any of them may be nil when the field is absent.
+type ReplyHeaders struct {
+	From    AddressList
+	ReplyTo AddressList
+	To      AddressList
+	Cc      AddressList
+
+	// MailFollowupTo and MailReplyTo are the Mail-Followup-To and
+	// Mail-Reply-To fields, which are not part of RFC 5322, but are widely
+	// used to direct replies on mailing lists.
+	MailFollowupTo AddressList
+	MailReplyTo    AddressList
+
+	// ListPost holds the posting address of the mailing list, such as the
+	// mailto URL of the List-Post field of RFC 2369, for use by ReplyList.
+	ListPost AddressList
+}
+
+// ReplyRecipients holds the destination fields of a reply.
+type ReplyRecipients struct {
+	To AddressList
+	Cc AddressList
+}
+
+// Replier computes the recipients of replies on behalf of a user. The
+// user's own addresses are never included among the recipients.
+type Replier struct {
+	// Identities are the addresses of the user. An address matches an
+	// identity when they are equal under EqualCanonical after any subaddress
+	// detail is removed from both, so "john+lists@example.com" matches
+	// "john@example.com".
+	Identities []*AddrSpec
+
+	// DetailSeparator separates the user from the detail of a subaddress.
+	DetailSeparator string
+}
+
+// NewReplier returns a Replier for the user with the given addresses. It
+// uses DefaultDetailSeparator.
+func NewReplier(identities ...*AddrSpec) *Replier {
+	return &Replier{
+		Identities:      identities,
+		DetailSeparator: DefaultDetailSeparator,
+	}
+}
+
+// identityKey returns the key used to compare the address to the identities.
+func (r *Replier) identityKey(as *AddrSpec) string {
+	return as.WithoutDetail(r.DetailSeparator).KeyFor(EqualCanonical)
+}
+
+// IsIdentity returns true if the address is one of the user's own.
+func (r *Replier) IsIdentity(as *AddrSpec) bool {
+	k := r.identityKey(as)
+	for _, id := range r.Identities {
+		if r.identityKey(id) == k {
+			return true
+		}
+	}
+	return false
+}
+
+// replyBuilder accumulates recipients, skipping the user's own addresses and
+// any address already added.
+type replyBuilder struct {
+	r    *Replier
+	seen map[string]bool
+}
+
+// keep returns true if the mailbox should be added, marking it as seen.
+func (b *replyBuilder) keep(mb *Mailbox) bool {
+	if mb == nil || b.r.IsIdentity(mb.address) {
+		return false
+	}
+
+	k := mb.address.KeyFor(EqualCanonical)
+	if b.seen[k] {
+		return false
+	}
+
+	b.seen[k] = true
+	return true
+}
+
+// add returns the list with the addresses appended. Each group is kept as a
+// group with only its remaining mailboxes and is left out if none remain.
+func (b *replyBuilder) add(dst, al AddressList) AddressList {
+	for _, a := range al {
+		g, ok := a.(*Group)
+		if !ok {
+			if b.keep(asMailbox(a)) {
+				dst = append(dst, a)
+			}
+			continue
+		}
+
+		var ms MailboxList
+		for _, mb := range g.mailboxList {
+			if b.keep(mb) {
+				ms = append(ms, mb)
+			}
+		}
+
+		switch {
+		case len(ms) == 0:
+			continue
+		case len(ms) < len(g.mailboxList):
+			g = &Group{displayName: g.displayName, mailboxList: ms, comments: g.comments}
+		}
+
+		dst = append(dst, g)
+	}
+
+	return dst
+}
+
+// firstNonEmpty returns the first of the lists that is not empty.
+func firstNonEmpty(lists ...AddressList) AddressList {
+	for _, l := range lists {
+		if len(l) > 0 {
+			return l
+		}
+	}
+	return nil
+}
+
+// Recipients returns the recipients of a reply to the message using the given
+// mode:
+//
+//   - ReplyAuthor replies to Mail-Reply-To, Reply-To, or From, whichever is
+//     found first.
+//   - ReplyAll replies to Mail-Followup-To if present. Otherwise, it replies
+//     to the author, as for ReplyAuthor, with a copy to every address of To
+//     and Cc.
+//   - ReplyList replies to Mail-Followup-To if present or else to ListPost.
+//     It returns ErrNoListAddress if both are empty.
+//
+// The user's own addresses are removed and duplicates are dropped using
+// EqualCanonical, keeping the first. Groups are kept as groups, less any
+// mailboxes removed; a group with no mailboxes left is dropped.
+//
+// If the author is the user, as when replying to a message the user sent, the
+// reply goes to the original To and Cc instead. If To would be left empty
+// while Cc is not, the Cc addresses are moved to To.
+func (r *Replier) Recipients(h *ReplyHeaders, mode ReplyMode) (*ReplyRecipients, error) {
+	b := &replyBuilder{r: r, seen: map[string]bool{}}
+	rr := &ReplyRecipients{}
+
+	author := firstNonEmpty(h.MailReplyTo, h.ReplyTo, h.From)
+	switch {
+	case mode == ReplyList || mode == ReplyAll && len(h.MailFollowupTo) > 0:
+		list := firstNonEmpty(h.MailFollowupTo, h.ListPost)
+		if mode == ReplyList && len(list) == 0 {
+			return nil, ErrNoListAddress
+		}
+		rr.To = b.add(nil, list)
+
+	default:
+		rr.To = b.add(nil, author)
+		if len(rr.To) == 0 {
+			rr.To = b.add(nil, h.To)
+			if mode == ReplyAll {
+				rr.Cc = b.add(nil, h.Cc)
+			}
+		} else if mode == ReplyAll {
+			rr.Cc = b.add(b.add(nil, h.To), h.Cc)
+		}
+	}
+
+	if len(rr.To) == 0 {
+		rr.To, rr.Cc = rr.Cc, nil
+	}
+
+	return rr, nil
+}
@@ -0,0 +1,152 @@
+package addr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func replyHeaders(t *testing.T, fields map[string]string) *ReplyHeaders {
+	t.Helper()
+
+	parse := func(name string) AddressList {
+		if fields[name] == "" {
+			return nil
+		}
+		return mustParseList(t, fields[name])
+	}
+
+	return &ReplyHeaders{
+		From:           parse("From"),
+		ReplyTo:        parse("Reply-To"),
+		To:             parse("To"),
+		Cc:             parse("Cc"),
+		MailFollowupTo: parse("Mail-Followup-To"),
+		MailReplyTo:    parse("Mail-Reply-To"),
+		ListPost:       parse("List-Post"),
+	}
+}
+
+func TestReplierRecipients(t *testing.T) {
+	t.Parallel()
+
+	r := NewReplier(NewAddrSpec("me", "example.com"), NewAddrSpec("me", "work.example"))
+
+	tests := []struct {
+		name   string
+		fields map[string]string
+		mode   ReplyMode
+		to, cc string
+	}{
+		{
+			name:   "reply to author",
+			fields: map[string]string{"From": "Alice <alice@example.net>", "To": "me@example.com", "Cc": "bob@example.net"},
+			mode:   ReplyAuthor,
+			to:     "Alice <alice@example.net>",
+		},
+		{
+			name:   "reply to Reply-To",
+			fields: map[string]string{"From": "alice@example.net", "Reply-To": "list@lists.example", "To": "me@example.com"},
+			mode:   ReplyAuthor,
+			to:     "list@lists.example",
+		},
+		{
+			name:   "reply to Mail-Reply-To",
+			fields: map[string]string{"From": "alice@example.net", "Reply-To": "list@lists.example", "Mail-Reply-To": "alice@home.example"},
+			mode:   ReplyAuthor,
+			to:     "alice@home.example",
+		},
+		{
+			name: "reply all",
+			fields: map[string]string{
+				"From": "Alice <alice@example.net>",
+				"To":   "me+lists@example.com, Bob <bob@example.net>",
+				"Cc":   "alice@Example.NET, carol@example.net, Me <me@WORK.example>",
+			},
+			mode: ReplyAll,
+			to:   "Alice <alice@example.net>",
+			cc:   "Bob <bob@example.net>, carol@example.net",
+		},
+		{
+			name: "reply all keeps groups",
+			fields: map[string]string{
+				"From": "alice@example.net",
+				"To":   "Team: me@example.com, bob@example.net, alice@example.net;, Empty: ;",
+			},
+			mode: ReplyAll,
+			to:   "alice@example.net",
+			cc:   "Team: bob@example.net;",
+		},
+		{
+			name: "reply all to Mail-Followup-To",
+			fields: map[string]string{
+				"From":             "alice@example.net",
+				"To":               "list@lists.example",
+				"Cc":               "bob@example.net",
+				"Mail-Followup-To": "list@lists.example, me@example.com",
+			},
+			mode: ReplyAll,
+			to:   "list@lists.example",
+		},
+		{
+			name:   "reply to list",
+			fields: map[string]string{"From": "alice@example.net", "To": "list@lists.example", "List-Post": "list@lists.example"},
+			mode:   ReplyList,
+			to:     "list@lists.example",
+		},
+		{
+			name:   "reply to own message",
+			fields: map[string]string{"From": "Me <me@example.com>", "To": "alice@example.net", "Cc": "bob@example.net"},
+			mode:   ReplyAuthor,
+			to:     "alice@example.net",
+		},
+		{
+			name:   "reply all to own message",
+			fields: map[string]string{"From": "Me <me@example.com>", "To": "alice@example.net", "Cc": "bob@example.net"},
+			mode:   ReplyAll,
+			to:     "alice@example.net",
+			cc:     "bob@example.net",
+		},
+		{
+			name:   "reply all when To is only me",
+			fields: map[string]string{"From": "me@example.com", "To": "me@work.example", "Cc": "bob@example.net"},
+			mode:   ReplyAll,
+			to:     "bob@example.net",
+		},
+	}
+
+	for _, tc := range tests {
+		rr, err := r.Recipients(replyHeaders(t, tc.fields), tc.mode)
+		if assert.NoError(t, err, tc.name) {
+			assert.Equal(t, tc.to, rr.To.String(), tc.name)
+			assert.Equal(t, tc.cc, rr.Cc.String(), tc.name)
+		}
+	}
+}
+
+func TestReplierRecipientsNoList(t *testing.T) {
+	t.Parallel()
+
+	r := NewReplier(NewAddrSpec("me", "example.com"))
+	_, err := r.Recipients(replyHeaders(t, map[string]string{"From": "alice@example.net"}), ReplyList)
+	assert.Equal(t, ErrNoListAddress, err)
+}
+
+func TestReplierIsIdentity(t *testing.T) {
+	t.Parallel()
+
+	r := NewReplier(NewAddrSpec("me+home", "Example.COM"))
+	assert.True(t, r.IsIdentity(NewAddrSpec("me", "example.com")))
+	assert.True(t, r.IsIdentity(NewAddrSpec("me+lists", "example.com")))
+	assert.False(t, r.IsIdentity(NewAddrSpec("ME", "example.com")))
+	assert.False(t, r.IsIdentity(NewAddrSpec("me", "example.net")))
+
+	r.DetailSeparator = "-"
+	assert.False(t, r.IsIdentity(NewAddrSpec("me+lists", "example.com")))
+
+	// a leading separator does not begin a detail
+	r = NewReplier(NewAddrSpec("+x", "example.com"))
+	assert.True(t, r.IsIdentity(NewAddrSpec("+x", "example.com")))
+	assert.False(t, r.IsIdentity(NewAddrSpec("+a", "example.com")))
+	assert.False(t, r.IsIdentity(NewAddrSpec("+b", "example.com")))
+}
@@ -1,9 +1,12 @@
 package addr
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/zostay/go-addr/pkg/format"
 )
 
 func TestMailboxRoundtrip(t *testing.T) {
@@ -30,3 +33,118 @@ func TestMailboxListRoundtripping(t *testing.T) {
 		mbs.OriginalString(),
 	)
 }
+
+func TestMailboxCleanStringRoundtrip(t *testing.T) {
+	t.Parallel()
+
+	names := []string{
+		"",
+		"Julia",
+		"Winston Smith",
+		"J.R.R. Tolkein",
+		"Sales, EMEA",
+		"Ünternehmen",
+		" padded  name ",
+		"\"quoted\" \\ name",
+		"=?utf-8?q?not_a_word?=",
+		"tab\there",
+		"line\r\nbreak",
+		"\x00\x7f",
+		strings.Repeat("Ünternehmen Gesellschaft ", 10),
+	}
+
+	comments := []string{
+		"",
+		"Records Department",
+		"¡Hola, señor!",
+		"back\\slash",
+		"nested (comment) here",
+		"unbalanced ) paren (",
+		" spaced ",
+		"=?utf-8?q?x?=",
+	}
+
+	addrSpecs := []*AddrSpec{
+		NewAddrSpec("user", "example.com"),
+		NewAddrSpec("first.last", "example.com"),
+		NewAddrSpec("with space", "example.com"),
+		NewAddrSpec(".dot", "example.com"),
+		NewAddrSpec("two..dots", "example.com"),
+		NewAddrSpec("quote\"back\\slash", "example.com"),
+		NewAddrSpec("", "example.com"),
+		NewAddrSpec("user", "[192.0.2.1]"),
+	}
+
+	for _, dn := range names {
+		for _, c := range comments {
+			for _, as := range addrSpecs {
+				mb := &Mailbox{
+					displayName: dn,
+					address:     as,
+					comments:    mailboxComments(c),
+				}
+
+				s := mb.CleanString()
+				mb2, err := ParseEmailMailbox(s)
+				if !assert.NoError(t, err, s) {
+					continue
+				}
+
+				// unsafe characters are dropped rather than rendered
+				assert.Equal(t, format.StripUnsafe(dn), mb2.DisplayName(), s)
+				assert.Equal(t, as.LocalPart(), mb2.LocalPart(), s)
+				assert.Equal(t, as.Domain(), mb2.Domain(), s)
+				assert.Equal(t, c, mb2.Comment(), s)
+			}
+		}
+	}
+}
+
+func TestGroupCleanStringRoundtrip(t *testing.T) {
+	t.Parallel()
+
+	mb1, err := NewMailboxStr("", "one@example.com", "first")
+	assert.NoError(t, err)
+	mb2, err := NewMailboxStr("Two, Esq.", "two@example.com", "")
+	assert.NoError(t, err)
+
+	names := []string{"", "Sales, EMEA", "Ünternehmen", "Team"}
+	lists := []MailboxList{nil, {mb1}, {mb1, mb2}}
+
+	for _, dn := range names {
+		for _, l := range lists {
+			g := NewGroupParsed(dn, l, "")
+
+			s := g.CleanString()
+			g2, err := ParseEmailGroup(s)
+			if !assert.NoError(t, err, s) {
+				continue
+			}
+
+			assert.Equal(t, dn, g2.DisplayName(), s)
+			if assert.Len(t, g2.MailboxList(), len(l), s) {
+				for i, mb := range l {
+					assert.Equal(t, mb.DisplayName(), g2.MailboxList()[i].DisplayName(), s)
+					assert.Equal(t, mb.AddrSpec().CleanString(), g2.MailboxList()[i].AddrSpec().CleanString(), s)
+					assert.Equal(t, mb.Comment(), g2.MailboxList()[i].Comment(), s)
+				}
+			}
+		}
+	}
+}
+
+func TestBareAddrSpecInLists(t *testing.T) {
+	t.Parallel()
+
+	al, err := ParseEmailAddressList("a@example.com (note), Team: b@example.com, c@example.com;")
+	assert.NoError(t, err)
+	if assert.Len(t, al, 2) {
+		assert.Equal(t, "a@example.com", al[0].(*Mailbox).AddrSpec().CleanString())
+		assert.Equal(t, "note", al[0].Comment())
+		assert.Len(t, al[1].(*Group).MailboxList(), 2)
+	}
+
+	ml, err := ParseEmailMailboxList("a@example.com, b@example.com")
+	assert.NoError(t, err)
+	assert.Len(t, ml, 2)
+}
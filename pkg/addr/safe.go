@@ -0,0 +1,235 @@
+package addr
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/zostay/go-addr/pkg/format"
+)
+
+// UnsafeTextError is returned when a display name, comment, or address contains
+// a character that could be used for header injection or to disguise text, such
+// as CR, LF, NUL, or a bidi override. See format.IsUnsafeRune for the complete
+// list.
+type UnsafeTextError struct {
+	Field  string // the part containing the character, e.g., "display name"
+	Rune   rune   // the unsafe character found
+	Offset int    // the byte offset of the character within the part
+}
+
+// Error returns a message describing the unsafe character and its location.
+func (e *UnsafeTextError) Error() string {
+	return fmt.Sprintf("unsafe character %U in %s at offset %d", e.Rune, e.Field, e.Offset)
+}
+
+// checkSafeText returns an *UnsafeTextError if the string contains an unsafe
+// character.
+func checkSafeText(field, s string) error {
+	if i := format.IndexUnsafe(s); i > -1 {
+		r, _ := utf8.DecodeRuneInString(s[i:])
+		return &UnsafeTextError{
+			Field:  field,
+			Rune:   r,
+			Offset: i,
+		}
+	}
+
+	return nil
+}
+
+// checkSafeComments returns an *UnsafeTextError if the text of any of the
+// comments contains an unsafe character.
+func checkSafeComments(cs []Comment) error {
+	for _, c := range cs {
+		if err := checkSafeText("comment", c.Text); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CheckSafe returns an *UnsafeTextError if the local part, domain, or any
+// comment of the address contains an unsafe character. NewAddrSpec does not
+// prevent this and the parser will decode such characters from quoted pairs in
+// a quoted local part, so this ought to be checked before the address is used
+// in a new message or an SMTP command.
+func (as *AddrSpec) CheckSafe() error {
+	if err := checkSafeText("local part", as.localPart); err != nil {
+		return err
+	}
+
+	if err := checkSafeText("domain", as.domain); err != nil {
+		return err
+	}
+
+	return checkSafeComments(as.comments)
+}
+
+// CheckSafe returns an *UnsafeTextError if the display name, any comment, or
+// the address of the mailbox contains an unsafe character. The constructors
+// and TrySet methods prevent this in the display name and comment, but
+// mailboxes produced by the parser may contain such characters (e.g., from
+// quoted pairs or MIME words), so this ought to be checked before using a
+// parsed mailbox to generate a new message.
+func (m *Mailbox) CheckSafe() error {
+	if err := checkSafeText("display name", m.displayName); err != nil {
+		return err
+	}
+
+	if err := checkSafeComments(m.comments); err != nil {
+		return err
+	}
+
+	return m.address.CheckSafe()
+}
+
+// stripUnsafeComments returns the comments with all unsafe characters removed
+// from their text and true if anything was removed. The comments are copied
+// rather than modified in place, keeping their positions. The raw text of each
+// changed comment is rebuilt from the stripped text.
+func stripUnsafeComments(cs []Comment) ([]Comment, bool) {
+	var out []Comment
+	for i, c := range cs {
+		t := format.StripUnsafe(c.Text)
+		if t == c.Text && format.IndexUnsafe(c.Raw) < 0 {
+			continue
+		}
+
+		if out == nil {
+			out = make([]Comment, len(cs))
+			copy(out, cs)
+		}
+		out[i] = newComment(c.Position, t)
+	}
+
+	if out == nil {
+		return cs, false
+	}
+
+	return out, true
+}
+
+// StripUnsafe removes all unsafe characters from the display name and comments
+// of the mailbox, including the comments within its address. Each comment keeps
+// its position. The original string is cleared if anything is removed. The
+// local part is left as is, since changing it would change the address; use
+// CheckSafe to detect an unsafe local part.
+func (m *Mailbox) StripUnsafe() {
+	dn := format.StripUnsafe(m.displayName)
+	if dn != m.displayName {
+		m.displayName = dn
+		m.original = ""
+	}
+
+	if cs, changed := stripUnsafeComments(m.comments); changed {
+		m.comments = cs
+		m.original = ""
+	}
+
+	if cs, changed := stripUnsafeComments(m.address.comments); changed {
+		as := *m.address
+		as.comments = cs
+		as.original = ""
+		m.address = &as
+		m.original = ""
+	}
+}
+
+// CheckSafe returns an *UnsafeTextError if the display name or any comment of
+// the group or any part of its mailboxes contains an unsafe character.
+func (g *Group) CheckSafe() error {
+	if err := checkSafeText("group display name", g.displayName); err != nil {
+		return err
+	}
+
+	if err := checkSafeComments(g.comments); err != nil {
+		return err
+	}
+
+	return g.mailboxList.CheckSafe()
+}
+
+// StripUnsafe removes all unsafe characters from the display name and comments
+// of the group and from every mailbox within it. The original string is cleared if anything
+// is removed.
+func (g *Group) StripUnsafe() {
+	dn := format.StripUnsafe(g.displayName)
+	if dn != g.displayName {
+		g.displayName = dn
+		g.original = ""
+	}
+
+	if cs, changed := stripUnsafeComments(g.comments); changed {
+		g.comments = cs
+		g.original = ""
+	}
+
+	for _, mb := range g.mailboxList {
+		o := mb.original
+		mb.StripUnsafe()
+		if mb.original != o {
+			g.original = ""
+		}
+	}
+}
+
+// CheckSafe returns the first *UnsafeTextError found in any mailbox of the
+// list.
+func (ms MailboxList) CheckSafe() error {
+	for _, m := range ms {
+		if err := m.CheckSafe(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// StripUnsafe removes all unsafe characters from every mailbox of the list.
+func (ms MailboxList) StripUnsafe() {
+	for _, m := range ms {
+		m.StripUnsafe()
+	}
+}
+
+// CheckSafe returns the first *UnsafeTextError found in any address of the
+// list. Addresses other than *Mailbox, *Group, and *AddrSpec have their
+// DisplayName and Comment checked.
+func (as AddressList) CheckSafe() error {
+	for _, a := range as {
+		var err error
+		switch v := a.(type) {
+		case *Mailbox:
+			err = v.CheckSafe()
+		case *Group:
+			err = v.CheckSafe()
+		case *AddrSpec:
+			err = v.CheckSafe()
+		default:
+			err = checkSafeText("display name", a.DisplayName())
+			if err == nil {
+				err = checkSafeText("comment", a.Comment())
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// StripUnsafe removes all unsafe characters from every *Mailbox and *Group in
+// the list.
+func (as AddressList) StripUnsafe() {
+	for _, a := range as {
+		switch v := a.(type) {
+		case *Mailbox:
+			v.StripUnsafe()
+		case *Group:
+			v.StripUnsafe()
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package addr
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zostay/go-addr/pkg/format"
+)
+
+func TestNewMailboxUnsafe(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewMailboxStr("Evil\r\nBcc: victim@example.com", "evil@example.com", "")
+	var ute *UnsafeTextError
+	if assert.True(t, errors.As(err, &ute)) {
+		assert.Equal(t, "display name", ute.Field)
+		assert.Equal(t, '\r', ute.Rune)
+		assert.Equal(t, 4, ute.Offset)
+	}
+
+	_, err = NewMailboxStr("", "evil@example.com", "gpj\u202e.exe")
+	if assert.True(t, errors.As(err, &ute)) {
+		assert.Equal(t, "comment", ute.Field)
+		assert.Equal(t, rune(0x202e), ute.Rune)
+	}
+
+	_, err = NewMailboxStr("Tab\tAllowed", "ok@example.com", "")
+	assert.NoError(t, err)
+}
+
+func TestSetUnsafe(t *testing.T) {
+	t.Parallel()
+
+	mb, err := NewMailboxStr("Name", "name@example.com", "")
+	assert.NoError(t, err)
+
+	assert.Error(t, mb.TrySetDisplayName("Bad\x00Name"))
+	assert.Equal(t, "Name", mb.DisplayName())
+
+	assert.Error(t, mb.TrySetComment("bad\ncomment"))
+	assert.Equal(t, "", mb.Comment())
+
+	assert.NoError(t, mb.TrySetComment("good comment"))
+	assert.Equal(t, "good comment", mb.Comment())
+
+	g := NewGroupParsed("Group", nil, "")
+	assert.Error(t, g.TrySetDisplayName("Bad\rGroup"))
+	assert.Equal(t, "Group", g.DisplayName())
+}
+
+func TestParsedUnsafe(t *testing.T) {
+	t.Parallel()
+
+	al, err := ParseEmailAddressList("=?utf-8?q?Evil=0D=0ABcc=3A_x?= <evil@example.com>, Team: \"a\\\rb\" <b@example.com>;")
+	assert.NoError(t, err)
+
+	var ute *UnsafeTextError
+	assert.True(t, errors.As(al.CheckSafe(), &ute))
+
+	_, err = al.FoldedHeader("To")
+	assert.True(t, errors.As(err, &ute))
+
+	al.StripUnsafe()
+	assert.NoError(t, al.CheckSafe())
+	assert.Equal(t, "EvilBcc: x", al[0].DisplayName())
+	assert.Equal(t, "", al[0].OriginalString())
+	assert.Equal(t, "ab", al[1].(*Group).MailboxList()[0].DisplayName())
+	assert.Equal(t, "", al[1].OriginalString())
+
+	_, err = al.FoldedHeader("To")
+	assert.NoError(t, err)
+}
+
+func TestStripUnsafeComments(t *testing.T) {
+	t.Parallel()
+
+	mb, err := ParseEmailMailbox("(be\\\nfore) Name <user(in\\\raddr)@example.com> (after)")
+	assert.NoError(t, err)
+	assert.Error(t, mb.CheckSafe())
+
+	as := mb.AddrSpec()
+	mb.StripUnsafe()
+	assert.NoError(t, mb.CheckSafe())
+	assert.Equal(t, "", mb.OriginalString())
+
+	assert.Equal(t, []Comment{
+		{CommentBeforeDisplayName, "before", "before"},
+		{CommentAfterLocalPart, "inaddr", "inaddr"},
+		{CommentAfterAngleAddr, "after", "after"},
+	}, mb.Comments())
+
+	// the address is copied rather than changed in place
+	assert.Equal(t, "in\raddr", as.Comment())
+	assert.Equal(t, "inaddr", mb.AddrSpec().Comment())
+}
+
+func TestNewGroupUnsafe(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewGroup("Bad\nGroup", nil)
+	var ute *UnsafeTextError
+	if assert.True(t, errors.As(err, &ute)) {
+		assert.Equal(t, "group display name", ute.Field)
+		assert.Equal(t, '\n', ute.Rune)
+	}
+
+	g, err := NewGroup("Good Group", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "Good Group", g.DisplayName())
+	assert.Equal(t, MailboxList{}, g.MailboxList())
+}
+
+func TestUnsafeLocalPart(t *testing.T) {
+	t.Parallel()
+
+	as := NewAddrSpec("a\r\nDATA", "example.com")
+	assert.Equal(t, "aDATA@example.com", as.CleanString())
+
+	var ute *UnsafeTextError
+	if assert.True(t, errors.As(as.CheckSafe(), &ute)) {
+		assert.Equal(t, "local part", ute.Field)
+		assert.Equal(t, '\r', ute.Rune)
+		assert.Equal(t, 1, ute.Offset)
+	}
+
+	mb, err := ParseEmailMailbox("Name <\"a\\\r\\\nDATA\"@example.com>")
+	assert.NoError(t, err)
+	assert.Equal(t, "a\r\nDATA", mb.LocalPart())
+	if assert.True(t, errors.As(mb.CheckSafe(), &ute)) {
+		assert.Equal(t, "local part", ute.Field)
+	}
+
+	assert.NotContains(t, mb.CleanString(), "\r")
+	assert.NotContains(t, mb.CleanString(), "\n")
+	for _, s := range []Style{StyleNameAddrComment, StyleNameAddr, StyleAddrName, StyleAddrOnly} {
+		out := Formatter{Style: s, PreferOriginal: true}.FormatMailbox(mb)
+		assert.Equal(t, -1, format.IndexUnsafe(out), out)
+	}
+
+	_, err = AddressList{mb}.FoldedHeader("To")
+	assert.True(t, errors.As(err, &ute))
+
+	_, err = AddressList{as}.FoldedHeader("To")
+	assert.True(t, errors.As(err, &ute))
+}
+
+func TestUnsafeRendering(t *testing.T) {
+	t.Parallel()
+
+	mb, err := ParseEmailMailbox("=?utf-8?q?Evil=0D=0A?= <evil@example.com> (=?utf-8?q?gpj=E2=80=AE.exe?=)")
+	assert.NoError(t, err)
+
+	f := Formatter{PreferOriginal: true}
+	assert.Equal(t, "Evil <evil@example.com> (gpj.exe)", f.FormatMailbox(mb))
+	assert.Equal(t, "evil@example.com (Evil)", Formatter{Style: StyleAddrName}.FormatMailbox(mb))
+
+	g := NewGroupParsed("Bad\x00Group", MailboxList{mb}, "")
+	assert.Equal(t, "BadGroup: Evil <evil@example.com> (gpj.exe);", g.CleanString())
+
+	var ute *UnsafeTextError
+	if assert.True(t, errors.As(g.CheckSafe(), &ute)) {
+		assert.Equal(t, "group display name", ute.Field)
+	}
+}
@@ -0,0 +1,419 @@
+package addr
+
+import (
+	"sort"
+	"strings"
+)
+
+// KeyFunc returns the key used by an AddressSet to decide whether two
+// mailboxes are the same. For example, (*Mailbox).Key treats mailboxes with
+// the same canonical address as the same.
+type KeyFunc func(*Mailbox) string
+
+// GroupMode selects how an AddressSet stores the groups added to it.
+type GroupMode int
+
+// These are the available group modes.
+const (
+	// FlattenGroups adds the mailboxes of each group to the set individually
+	// and discards the group itself.
+	FlattenGroups GroupMode = iota
+
+	// KeepGroups stores each group as a single entry. Two groups are the same
+	// if they have the same display name and the same set of mailboxes. The
+	// mailboxes of a group are not entries of the set themselves, so a group
+	// and a mailbox within it may both be in the set.
+	KeepGroups
+)
+
+// organizationDomain returns the registrable domain of the given canonical
+// domain or the domain itself if it has none.
+func organizationDomain(d string) string {
+	if rd := registrableDomain(d); rd != "" {
+		return rd
+	}
+
+	return d
+}
+
+// setEntry is a single entry of an AddressSet.
+type setEntry struct {
+	address Address // either a *Mailbox or a *Group
+	key     string
+	seq     int
+	domains []string
+	orgDoms []string // the organization domains of domains when added
+}
+
+// AddressSet is a set of addresses that keeps the order in which addresses were
+// added. Each mailbox is identified by a key returned by the KeyFunc of the set,
+// so duplicates are detected according to whatever normalization the key
+// applies. Entries are indexed by domain and registrable domain (e.g.,
+// "example.co.uk" for "mail.example.co.uk").
+//
+// All operations other than listing the entries take time proportional to the
+// number of addresses involved, not to the size of the set.
+type AddressSet struct {
+	key       KeyFunc
+	groupMode GroupMode
+
+	entries []*setEntry
+	removed int
+	seq     int
+
+	index    map[string]*setEntry
+	byDomain map[string]map[*setEntry]struct{}
+	byRegDom map[string]map[*setEntry]struct{}
+}
+
+// NewAddressSet returns an empty set using the given key function and group
+// mode. If key is nil, (*Mailbox).Key is used.
+func NewAddressSet(key KeyFunc, gm GroupMode) *AddressSet {
+	if key == nil {
+		key = (*Mailbox).Key
+	}
+
+	return &AddressSet{
+		key:       key,
+		groupMode: gm,
+		index:     map[string]*setEntry{},
+		byDomain:  map[string]map[*setEntry]struct{}{},
+		byRegDom:  map[string]map[*setEntry]struct{}{},
+	}
+}
+
+// NewAddressSetFromList returns a set using the given key function and group
+// mode holding the addresses of the list.
+func NewAddressSetFromList(as AddressList, key KeyFunc, gm GroupMode) *AddressSet {
+	s := NewAddressSet(key, gm)
+	s.AddList(as)
+	return s
+}
+
+// groupKey returns the key of the group within the set.
+func (s *AddressSet) groupKey(g *Group) string {
+	ks := make([]string, len(g.mailboxList))
+	for i, mb := range g.mailboxList {
+		ks[i] = s.key(mb)
+	}
+	sort.Strings(ks)
+
+	return "\x00group\x00" + canonicalText(g.displayName) + "\x00" + strings.Join(ks, "\x00")
+}
+
+// entryOf returns the address as it would be stored in the set, which is
+// either a *Mailbox or a *Group, and its key. It returns nil if the address
+// cannot be stored.
+func (s *AddressSet) entryOf(a Address) (Address, string) {
+	if g, ok := a.(*Group); ok {
+		return g, s.groupKey(g)
+	}
+
+	mb := asMailbox(a)
+	if mb == nil {
+		return nil, ""
+	}
+
+	return mb, s.key(mb)
+}
+
+// Add adds the address to the set and returns true if the set has changed.
+// A bare *AddrSpec is added as a mailbox. A *Group is handled according to the
+// GroupMode of the set. Any other Address is converted to a mailbox as is done
+// by AddressList.Flatten and is not added if that fails.
+//
+// If a mailbox already in the set has the same key, no entry is added, but the
+// display name or comment of the new mailbox is merged into the entry if the
+// entry has none. The mailbox in the set is then replaced by a copy, so the
+// mailboxes given are never modified.
+func (s *AddressSet) Add(a Address) bool {
+	if g, ok := a.(*Group); ok && s.groupMode == FlattenGroups {
+		changed := false
+		for _, mb := range g.mailboxList {
+			if s.Add(mb) {
+				changed = true
+			}
+		}
+		return changed
+	}
+
+	a, k := s.entryOf(a)
+	if a == nil {
+		return false
+	}
+
+	if e, ok := s.index[k]; ok {
+		return s.merge(e, a)
+	}
+
+	e := &setEntry{
+		address: a,
+		key:     k,
+		seq:     s.seq,
+	}
+	s.seq++
+
+	switch v := a.(type) {
+	case *Group:
+		for _, mb := range v.mailboxList {
+			e.domains = append(e.domains, domainKey(mb.Domain()))
+		}
+	case *Mailbox:
+		e.domains = []string{domainKey(v.Domain())}
+	}
+
+	e.orgDoms = make([]string, len(e.domains))
+	for i, d := range e.domains {
+		e.orgDoms[i] = organizationDomain(d)
+	}
+
+	s.entries = append(s.entries, e)
+	s.index[k] = e
+	for i, d := range e.domains {
+		addToIndex(s.byDomain, d, e)
+		addToIndex(s.byRegDom, e.orgDoms[i], e)
+	}
+
+	return true
+}
+
+// merge copies the display name and comment of the address into the entry if
+// the entry has none and returns true if anything changed.
+func (s *AddressSet) merge(e *setEntry, a Address) bool {
+	old, ok := e.address.(*Mailbox)
+	if !ok {
+		return false
+	}
+
+	mb := a.(*Mailbox)
+	oc, mc := old.Comment(), mb.Comment()
+	if (old.displayName != "" || mb.displayName == "") && (oc != "" || mc == "") {
+		return false
+	}
+
+	merged := *old
+	if merged.displayName == "" {
+		merged.displayName = mb.displayName
+	}
+	if oc == "" {
+		merged.comments = mailboxComments(mc)
+	}
+	merged.original = ""
+
+	e.address = &merged
+	return true
+}
+
+// AddList adds every address of the list to the set.
+func (s *AddressSet) AddList(as AddressList) {
+	for _, a := range as {
+		s.Add(a)
+	}
+}
+
+// AddMailboxList adds every mailbox of the list to the set.
+func (s *AddressSet) AddMailboxList(ms MailboxList) {
+	for _, mb := range ms {
+		s.Add(mb)
+	}
+}
+
+// Remove removes the entry with the same key as the address and returns true
+// if there was one. Under FlattenGroups, removing a *Group removes each of its
+// mailboxes.
+func (s *AddressSet) Remove(a Address) bool {
+	if g, ok := a.(*Group); ok && s.groupMode == FlattenGroups {
+		changed := false
+		for _, mb := range g.mailboxList {
+			if s.Remove(mb) {
+				changed = true
+			}
+		}
+		return changed
+	}
+
+	ea, k := s.entryOf(a)
+	e, ok := s.index[k]
+	if ea == nil || !ok {
+		return false
+	}
+
+	delete(s.index, e.key)
+	for i, d := range e.domains {
+		removeFromIndex(s.byDomain, d, e)
+		removeFromIndex(s.byRegDom, e.orgDoms[i], e)
+	}
+
+	e.address = nil
+	s.removed++
+	if s.removed > len(s.entries)/2 {
+		s.compact()
+	}
+
+	return true
+}
+
+// compact drops removed entries from the ordered list of entries.
+func (s *AddressSet) compact() {
+	live := s.entries[:0]
+	for _, e := range s.entries {
+		if e.address != nil {
+			live = append(live, e)
+		}
+	}
+
+	for i := len(live); i < len(s.entries); i++ {
+		s.entries[i] = nil
+	}
+
+	s.entries = live
+	s.removed = 0
+}
+
+// addToIndex records the entry under the given index key.
+func addToIndex(idx map[string]map[*setEntry]struct{}, k string, e *setEntry) {
+	es, ok := idx[k]
+	if !ok {
+		es = map[*setEntry]struct{}{}
+		idx[k] = es
+	}
+	es[e] = struct{}{}
+}
+
+// removeFromIndex removes the entry from the given index key.
+func removeFromIndex(idx map[string]map[*setEntry]struct{}, k string, e *setEntry) {
+	if es, ok := idx[k]; ok {
+		delete(es, e)
+		if len(es) == 0 {
+			delete(idx, k)
+		}
+	}
+}
+
+// Contains returns true if the set has an entry with the same key as the
+// address. Under FlattenGroups, a *Group is contained if all of its mailboxes
+// are.
+func (s *AddressSet) Contains(a Address) bool {
+	if g, ok := a.(*Group); ok && s.groupMode == FlattenGroups {
+		for _, mb := range g.mailboxList {
+			if !s.Contains(mb) {
+				return false
+			}
+		}
+		return true
+	}
+
+	ea, k := s.entryOf(a)
+	_, ok := s.index[k]
+	return ea != nil && ok
+}
+
+// Get returns the entry of the set with the same key as the address or nil if
+// there is none.
+func (s *AddressSet) Get(a Address) Address {
+	if ea, k := s.entryOf(a); ea != nil {
+		if e, ok := s.index[k]; ok {
+			return e.address
+		}
+	}
+
+	return nil
+}
+
+// Len returns the number of entries in the set.
+func (s *AddressSet) Len() int { return len(s.index) }
+
+// AddressList returns the entries of the set in the order they were added.
+func (s *AddressSet) AddressList() AddressList {
+	as := make(AddressList, 0, s.Len())
+	for _, e := range s.entries {
+		if e.address != nil {
+			as = append(as, e.address)
+		}
+	}
+	return as
+}
+
+// MailboxList returns the mailboxes of the set in the order they were added.
+// Under KeepGroups, the mailboxes of each group are included in place of the
+// group.
+func (s *AddressSet) MailboxList() MailboxList {
+	return s.AddressList().Flatten()
+}
+
+// sortedEntries returns the entries as an AddressList in the order they were
+// added.
+func sortedEntries(es map[*setEntry]struct{}) AddressList {
+	ses := make([]*setEntry, 0, len(es))
+	for e := range es {
+		ses = append(ses, e)
+	}
+	sort.Slice(ses, func(i, j int) bool { return ses[i].seq < ses[j].seq })
+
+	as := make(AddressList, len(ses))
+	for i, e := range ses {
+		as[i] = e.address
+	}
+	return as
+}
+
+// ByDomain returns the entries with an address at the given domain in the
+// order they were added. The domain is compared in its canonical form. Under
+// KeepGroups, a group is returned if any of its mailboxes is at the domain.
+func (s *AddressSet) ByDomain(d string) AddressList {
+	return sortedEntries(s.byDomain[domainKey(d)])
+}
+
+// ByRegistrableDomain returns the entries with an address at the given
+// registrable domain or any of its subdomains in the order they were added.
+// If the domain given is not itself a registrable domain, its registrable
+// domain is used instead. Entries are indexed using the public suffix list in
+// use when they were added (see SetDefaultPublicSuffixList).
+func (s *AddressSet) ByRegistrableDomain(d string) AddressList {
+	return sortedEntries(s.byRegDom[organizationDomain(domainKey(d))])
+}
+
+// Domains returns the canonical domains of the entries in the set, sorted.
+func (s *AddressSet) Domains() []string {
+	ds := make([]string, 0, len(s.byDomain))
+	for d := range s.byDomain {
+		ds = append(ds, d)
+	}
+	sort.Strings(ds)
+	return ds
+}
+
+// Union returns a new set with the same key function and group mode holding
+// the entries of this set followed by the entries of the other set that are
+// not in this one.
+func (s *AddressSet) Union(other *AddressSet) *AddressSet {
+	u := NewAddressSetFromList(s.AddressList(), s.key, s.groupMode)
+	u.AddList(other.AddressList())
+	return u
+}
+
+// Intersect returns a new set with the same key function and group mode
+// holding the entries of this set that the other set contains. Membership in
+// the other set is decided by its own key function.
+func (s *AddressSet) Intersect(other *AddressSet) *AddressSet {
+	i := NewAddressSet(s.key, s.groupMode)
+	for _, a := range s.AddressList() {
+		if other.Contains(a) {
+			i.Add(a)
+		}
+	}
+	return i
+}
+
+// Difference returns a new set with the same key function and group mode
+// holding the entries of this set that the other set does not contain.
+// Membership in the other set is decided by its own key function.
+func (s *AddressSet) Difference(other *AddressSet) *AddressSet {
+	d := NewAddressSet(s.key, s.groupMode)
+	for _, a := range s.AddressList() {
+		if !other.Contains(a) {
+			d.Add(a)
+		}
+	}
+	return d
+}
@@ -0,0 +1,142 @@
+package addr
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddressSetDedupe(t *testing.T) {
+	t.Parallel()
+
+	s := NewAddressSetFromList(mustParseList(t,
+		`ender@example.com, Bean <bean@example.com>, "Andrew Wiggin" <ender@EXAMPLE.com>, bean@example.com`,
+	), nil, FlattenGroups)
+
+	assert.Equal(t, 2, s.Len())
+	assert.Equal(t, `"Andrew Wiggin" <ender@example.com>, Bean <bean@example.com>`, s.AddressList().CleanString())
+
+	assert.True(t, s.Contains(NewAddrSpec("ender", "Example.com")))
+	assert.False(t, s.Contains(NewAddrSpec("petra", "example.com")))
+	assert.Equal(t, "Bean", s.Get(NewAddrSpec("bean", "example.com")).DisplayName())
+	assert.Nil(t, s.Get(NewAddrSpec("petra", "example.com")))
+
+	assert.False(t, s.Add(NewAddrSpec("bean", "example.com")))
+	assert.True(t, s.Add(NewAddrSpec("petra", "example.com")))
+	assert.Equal(t, 3, s.Len())
+}
+
+func TestAddressSetKeyFunc(t *testing.T) {
+	t.Parallel()
+
+	exact := func(mb *Mailbox) string { return mb.KeyFor(EqualExact) }
+
+	s := NewAddressSetFromList(mustParseList(t,
+		"ender@example.com, ender@EXAMPLE.com",
+	), exact, FlattenGroups)
+
+	assert.Equal(t, 2, s.Len())
+}
+
+func TestAddressSetGroups(t *testing.T) {
+	t.Parallel()
+
+	al := mustParseList(t,
+		"Jeesh: ender@example.com, bean@example.com;, ender@example.com, Jeesh: bean@example.com, ender@example.com;")
+
+	fs := NewAddressSetFromList(al, nil, FlattenGroups)
+	assert.Equal(t, "ender@example.com, bean@example.com", fs.AddressList().CleanString())
+	assert.True(t, fs.Contains(al[0]))
+
+	ks := NewAddressSetFromList(al, nil, KeepGroups)
+	assert.Equal(t, "Jeesh: ender@example.com, bean@example.com;, ender@example.com", ks.AddressList().CleanString())
+	assert.Equal(t, "ender@example.com, bean@example.com, ender@example.com", ks.MailboxList().CleanString())
+	assert.True(t, ks.Contains(al[2]))
+	assert.False(t, ks.Contains(NewAddrSpec("bean", "example.com")))
+
+	assert.True(t, ks.Remove(al[2]))
+	assert.Equal(t, 1, ks.Len())
+	assert.True(t, fs.Remove(al[2]))
+	assert.Equal(t, 0, fs.Len())
+}
+
+func TestAddressSetDomains(t *testing.T) {
+	t.Parallel()
+
+	s := NewAddressSetFromList(mustParseList(t,
+		"a@mail.example.co.uk, b@EXAMPLE.co.uk, c@example.com, d@other.example.co.uk, Team: e@example.co.uk;",
+	), nil, KeepGroups)
+
+	assert.Equal(t, []string{
+		"example.co.uk",
+		"example.com",
+		"mail.example.co.uk",
+		"other.example.co.uk",
+	}, s.Domains())
+
+	assert.Equal(t, "b@EXAMPLE.co.uk, Team: e@example.co.uk;", s.ByDomain("Example.CO.uk").CleanString())
+	assert.Equal(t, "a@mail.example.co.uk, b@EXAMPLE.co.uk, d@other.example.co.uk, Team: e@example.co.uk;",
+		s.ByRegistrableDomain("mail.example.co.uk").CleanString())
+	assert.Empty(t, s.ByDomain("nowhere.example"))
+
+	s.Remove(NewAddrSpec("b", "example.co.uk"))
+	assert.Equal(t, "Team: e@example.co.uk;", s.ByDomain("example.co.uk").CleanString())
+}
+
+func TestAddressSetOperations(t *testing.T) {
+	t.Parallel()
+
+	a := NewAddressSetFromList(mustParseList(t, "a@example.com, b@example.com, c@example.com"), nil, FlattenGroups)
+	b := NewAddressSetFromList(mustParseList(t, "B@example.com, c@EXAMPLE.com, d@example.com"), nil, FlattenGroups)
+
+	assert.Equal(t, "a@example.com, b@example.com, c@example.com, B@example.com, d@example.com",
+		a.Union(b).AddressList().CleanString())
+	assert.Equal(t, "c@example.com", a.Intersect(b).AddressList().CleanString())
+	assert.Equal(t, "a@example.com, b@example.com", a.Difference(b).AddressList().CleanString())
+
+	// the operands are unchanged
+	assert.Equal(t, 3, a.Len())
+	assert.Equal(t, 3, b.Len())
+}
+
+func TestAddressSetCompact(t *testing.T) {
+	t.Parallel()
+
+	s := NewAddressSet(nil, FlattenGroups)
+	for _, c := range "abcdefghij" {
+		s.Add(NewAddrSpec(string(c), "example.com"))
+	}
+
+	for _, c := range "abcdefg" {
+		assert.True(t, s.Remove(NewAddrSpec(string(c), "example.com")))
+	}
+	assert.False(t, s.Remove(NewAddrSpec("a", "example.com")))
+
+	assert.Equal(t, "h@example.com, i@example.com, j@example.com", s.AddressList().CleanString())
+	assert.Equal(t, 3, s.Len())
+
+	s.Add(NewAddrSpec("a", "example.com"))
+	assert.Equal(t, "h@example.com, i@example.com, j@example.com, a@example.com", s.AddressList().CleanString())
+}
+
+// TestAddressSetSuffixListChange is not run in parallel as it replaces the
+// default public suffix list used by the other tests.
+func TestAddressSetSuffixListChange(t *testing.T) {
+	l, err := ParsePublicSuffixList(strings.NewReader("uk\nco.uk\nexample.co.uk\n"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	s := NewAddressSet(nil, FlattenGroups)
+	s.Add(NewAddrSpec("a", "foo.example.co.uk"))
+	assert.Len(t, s.ByRegistrableDomain("example.co.uk"), 1)
+
+	SetDefaultPublicSuffixList(l)
+	assert.True(t, s.Remove(NewAddrSpec("a", "foo.example.co.uk")))
+	SetDefaultPublicSuffixList(nil)
+
+	assert.Empty(t, s.ByRegistrableDomain("foo.example.co.uk"))
+	assert.Empty(t, s.ByRegistrableDomain("example.co.uk"))
+	assert.Empty(t, s.Domains())
+}
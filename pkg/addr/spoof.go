@@ -0,0 +1,385 @@
+package addr
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// RestrictionLevel is a restriction level of Unicode Technical Standard #39,
+// which describes how the scripts of an identifier are mixed. Levels are
+// ordered from most to least restrictive.
+type RestrictionLevel int
+
+// These are the restriction levels.
+const (
+	// ASCIIOnly means every character is ASCII.
+	ASCIIOnly RestrictionLevel = iota
+
+	// SingleScript means every character is from a single script, ignoring
+	// characters common to all scripts, such as digits and punctuation.
+	SingleScript
+
+	// HighlyRestrictive means the characters are from one of the script
+	// combinations commonly used together: Latin with Han, Hiragana, and
+	// Katakana; Latin with Han and Bopomofo; or Latin with Han and Hangul.
+	HighlyRestrictive
+
+	// ModeratelyRestrictive means the characters are from Latin and one other
+	// script, which is not Cyrillic or Greek.
+	ModeratelyRestrictive
+
+	// MinimallyRestrictive means the characters are from any mix of scripts.
+	MinimallyRestrictive
+)
+
+var restrictionLevelNames = []string{
+	"ASCII-only",
+	"single script",
+	"highly restrictive",
+	"moderately restrictive",
+	"minimally restrictive",
+}
+
+// String returns the name of the restriction level.
+func (l RestrictionLevel) String() string {
+	if l < 0 || int(l) >= len(restrictionLevelNames) {
+		return "unknown"
+	}
+
+	return restrictionLevelNames[l]
+}
+
+// scriptTables are the scripts distinguished when computing a restriction
+// level. Characters from any other script are counted as a script of their
+// own.
+var scriptTables = map[string]*unicode.RangeTable{
+	"Arabic":     unicode.Arabic,
+	"Armenian":   unicode.Armenian,
+	"Bopomofo":   unicode.Bopomofo,
+	"Cyrillic":   unicode.Cyrillic,
+	"Devanagari": unicode.Devanagari,
+	"Georgian":   unicode.Georgian,
+	"Greek":      unicode.Greek,
+	"Han":        unicode.Han,
+	"Hangul":     unicode.Hangul,
+	"Hebrew":     unicode.Hebrew,
+	"Hiragana":   unicode.Hiragana,
+	"Katakana":   unicode.Katakana,
+	"Latin":      unicode.Latin,
+	"Thai":       unicode.Thai,
+}
+
+// scriptOf returns the name of the script of the rune or the empty string if
+// the rune is common to all scripts.
+func scriptOf(r rune) string {
+	if unicode.In(r, unicode.Common, unicode.Inherited) {
+		return ""
+	}
+
+	for name, t := range scriptTables {
+		if unicode.Is(t, r) {
+			return name
+		}
+	}
+
+	return "Other"
+}
+
+// scriptsOf returns the set of scripts used by the string.
+func scriptsOf(s string) map[string]bool {
+	ss := map[string]bool{}
+	for _, r := range s {
+		if sc := scriptOf(r); sc != "" {
+			ss[sc] = true
+		}
+	}
+	return ss
+}
+
+// subsetOf returns true if every script of ss is listed.
+func subsetOf(ss map[string]bool, scripts ...string) bool {
+	n := 0
+	for _, sc := range scripts {
+		if ss[sc] {
+			n++
+		}
+	}
+	return n == len(ss)
+}
+
+// RestrictionLevelOf returns the restriction level of the string.
+func RestrictionLevelOf(s string) RestrictionLevel {
+	if strings.IndexFunc(s, func(r rune) bool { return r > unicode.MaxASCII }) < 0 {
+		return ASCIIOnly
+	}
+
+	ss := scriptsOf(s)
+	switch {
+	case len(ss) <= 1:
+		return SingleScript
+	case subsetOf(ss, "Latin", "Han", "Hiragana", "Katakana"),
+		subsetOf(ss, "Latin", "Han", "Bopomofo"),
+		subsetOf(ss, "Latin", "Han", "Hangul"):
+		return HighlyRestrictive
+	case len(ss) == 2 && ss["Latin"] && !ss["Cyrillic"] && !ss["Greek"]:
+		return ModeratelyRestrictive
+	default:
+		return MinimallyRestrictive
+	}
+}
+
+// confusables maps characters to the prototype they are easily confused with,
+// following the confusables data of Unicode Technical Standard #39. Only the
+// characters most often used to imitate Latin letters are included.
+var confusables = map[rune]string{
+	// digits and ASCII
+	'0': "o", '1': "l", '|': "l", 'm': "rn",
+
+	// Latin
+	'ı': "i", 'ɩ': "i", 'ɡ': "g", 'ℓ': "l",
+
+	// Cyrillic
+	'а': "a", 'ԁ': "d", 'е': "e", 'һ': "h", 'і': "i", 'ј': "j", 'к': "k",
+	'ӏ': "l", 'м': "rn", 'п': "n", 'о': "o", 'р': "p", 'ԛ': "q", 'с': "c",
+	'ѕ': "s", 'т': "t", 'у': "y", 'ԝ': "w", 'х': "x", 'ь': "b",
+
+	// Greek
+	'α': "a", 'ε': "e", 'η': "n", 'ι': "i", 'κ': "k", 'ν': "v", 'ο': "o",
+	'ρ': "p", 'τ': "t", 'υ': "u", 'ω': "w", 'χ': "x", 'γ': "y",
+}
+
+// Skeleton returns the skeleton of the string as described by Unicode
+// Technical Standard #39: two strings that look alike have the same skeleton.
+// Only the most common confusable characters are mapped. The string is
+// lowercased first, so the skeleton is meant for comparing domains.
+func Skeleton(s string) string {
+	s = norm.NFD.String(strings.ToLower(s))
+
+	var b strings.Builder
+	for _, r := range s {
+		if p, ok := confusables[r]; ok {
+			b.WriteString(p)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+
+	return norm.NFD.String(b.String())
+}
+
+// isBidiControl returns true if the rune is one of the invisible characters
+// that change the direction in which text is displayed. Unlike
+// format.IsUnsafeRune, this includes the directional marks. Parsed display
+// names may contain any of them when decoded from MIME words.
+func isBidiControl(r rune) bool {
+	switch {
+	case r == '\u061c', r == '\u200e', r == '\u200f':
+		return true
+	case r >= '\u202a' && r <= '\u202e':
+		return true
+	case r >= '\u2066' && r <= '\u2069':
+		return true
+	default:
+		return false
+	}
+}
+
+// SpoofKind identifies the kind of a SpoofFinding.
+type SpoofKind string
+
+// These are the kinds of spoof finding.
+const (
+	SpoofMixedScript      SpoofKind = "mixed script"
+	SpoofConfusableDomain SpoofKind = "confusable domain"
+	SpoofBidiControl      SpoofKind = "bidi control"
+	SpoofEmbeddedAddress  SpoofKind = "embedded address"
+)
+
+// These are the parts of a mailbox in which a spoof finding is made.
+const (
+	PartDisplayName = "display name"
+	PartLocalPart   = "local part"
+	PartDomain      = "domain"
+)
+
+// SpoofFinding describes a single way in which a mailbox may be imitating
+// another.
+type SpoofFinding struct {
+	Kind      SpoofKind        // the kind of finding
+	Part      string           // the part of the mailbox, e.g., PartDomain
+	Text      string           // the text of the part found, in Unicode form
+	Level     RestrictionLevel // the restriction level of the text
+	Protected string           // the protected domain imitated, if any
+}
+
+// builtinProtectedDomains are the domains each new SpoofChecker protects.
+var builtinProtectedDomains = []string{
+	"amazon.com", "apple.com", "bankofamerica.com", "chase.com", "dropbox.com",
+	"facebook.com", "github.com", "gmail.com", "google.com", "icloud.com",
+	"instagram.com", "linkedin.com", "microsoft.com", "netflix.com",
+	"office.com", "outlook.com", "paypal.com", "wellsfargo.com", "yahoo.com",
+}
+
+// SpoofChecker looks for signs that a mailbox imitates another: scripts mixed
+// beyond a restriction level, domains that look like a protected domain,
+// display names containing bidi control characters, and display names or
+// comments containing a different address (see Mailbox.EmbeddedAddresses). It
+// is safe for concurrent use.
+//
+// The zero value protects no domains and has a MaxLevel of ASCIIOnly, so every
+// internationalized address is reported. Use NewSpoofChecker for a checker
+// permitting HighlyRestrictive text.
+type SpoofChecker struct {
+	// MaxLevel is the least restrictive level permitted for the local part
+	// and each label of the domain. NewSpoofChecker sets this to
+	// HighlyRestrictive.
+	MaxLevel RestrictionLevel
+
+	lock      sync.RWMutex
+	protected map[string]map[string]bool // sets of protected domains by skeleton
+}
+
+// DefaultSpoofChecker is the checker used by CheckSpoofing. It starts with a
+// built-in list of protected domains, to which more may be added at any time.
+var DefaultSpoofChecker = NewSpoofChecker(builtinProtectedDomains...)
+
+// NewSpoofChecker returns a checker protecting the given domains.
+func NewSpoofChecker(protected ...string) *SpoofChecker {
+	c := &SpoofChecker{
+		MaxLevel:  HighlyRestrictive,
+		protected: map[string]map[string]bool{},
+	}
+	c.Protect(protected...)
+	return c
+}
+
+// unicodeDomain returns the domain in lowercase Unicode form.
+func unicodeDomain(d string) string {
+	d = domainKey(d)
+	if u, err := idnaProfile.ToUnicode(d); err == nil {
+		return u
+	}
+	return d
+}
+
+// Protect adds domains to the protected list. Protected domains may look alike,
+// e.g., "rn.com" and "m.com", in which case neither is reported as confusable
+// with the other.
+func (c *SpoofChecker) Protect(domains ...string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.protected == nil {
+		c.protected = map[string]map[string]bool{}
+	}
+
+	for _, d := range domains {
+		u := unicodeDomain(d)
+		sk := Skeleton(u)
+		if c.protected[sk] == nil {
+			c.protected[sk] = map[string]bool{}
+		}
+		c.protected[sk][u] = true
+	}
+}
+
+// confusableWith returns the protected domain that the domain looks like, but
+// is not. If the domain looks like several protected domains, the first in
+// sorted order is returned.
+func (c *SpoofChecker) confusableWith(d string) (string, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	cands := []string{d}
+	if rd := registrableDomain(d); rd != "" && rd != d {
+		cands = append(cands, unicodeDomain(rd))
+	}
+
+	for _, cand := range cands {
+		ps := c.protected[Skeleton(cand)]
+		if ps[cand] {
+			continue
+		}
+
+		first := ""
+		for p := range ps {
+			if first == "" || p < first {
+				first = p
+			}
+		}
+
+		if first != "" {
+			return first, true
+		}
+	}
+
+	return "", false
+}
+
+// Check returns every finding about the mailbox, or nil if there is nothing
+// suspicious about it.
+func (c *SpoofChecker) Check(mb *Mailbox) []SpoofFinding {
+	var fs []SpoofFinding
+
+	if dn := mb.DisplayName(); strings.IndexFunc(dn, isBidiControl) >= 0 {
+		fs = append(fs, SpoofFinding{
+			Kind:  SpoofBidiControl,
+			Part:  PartDisplayName,
+			Text:  dn,
+			Level: RestrictionLevelOf(dn),
+		})
+	}
+
+	for _, e := range mb.EmbeddedAddresses() {
+		if !e.Matches {
+			fs = append(fs, SpoofFinding{
+				Kind:  SpoofEmbeddedAddress,
+				Part:  e.Part,
+				Text:  e.Text,
+				Level: RestrictionLevelOf(e.Text),
+			})
+		}
+	}
+
+	lp := mb.LocalPart()
+	if l := RestrictionLevelOf(lp); l > c.MaxLevel {
+		fs = append(fs, SpoofFinding{Kind: SpoofMixedScript, Part: PartLocalPart, Text: lp, Level: l})
+	}
+
+	if strings.HasPrefix(mb.Domain(), "[") {
+		return fs
+	}
+
+	d := unicodeDomain(mb.Domain())
+	level := ASCIIOnly
+	for _, label := range strings.Split(d, ".") {
+		if l := RestrictionLevelOf(label); l > level {
+			level = l
+		}
+	}
+
+	if level > c.MaxLevel {
+		fs = append(fs, SpoofFinding{Kind: SpoofMixedScript, Part: PartDomain, Text: d, Level: level})
+	}
+
+	if p, ok := c.confusableWith(d); ok {
+		fs = append(fs, SpoofFinding{
+			Kind:      SpoofConfusableDomain,
+			Part:      PartDomain,
+			Text:      d,
+			Level:     level,
+			Protected: p,
+		})
+	}
+
+	return fs
+}
+
+// CheckSpoofing checks the mailbox using DefaultSpoofChecker. See
+// SpoofChecker.Check.
+func CheckSpoofing(mb *Mailbox) []SpoofFinding {
+	return DefaultSpoofChecker.Check(mb)
+}
@@ -0,0 +1,139 @@
+package addr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestrictionLevelOf(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in    string
+		level RestrictionLevel
+	}{
+		{"paypal", ASCIIOnly},
+		{"bücher", SingleScript},
+		{"пример", SingleScript},
+		{"abc漢字ひらがな", HighlyRestrictive},
+		{"abc한국어", HighlyRestrictive},
+		{"abcשלום", ModeratelyRestrictive},
+		{"аpple", MinimallyRestrictive},
+		{"pαypal", MinimallyRestrictive},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.level, RestrictionLevelOf(tc.in), tc.in)
+	}
+
+	assert.Equal(t, "highly restrictive", HighlyRestrictive.String())
+}
+
+func TestSkeleton(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, Skeleton("paypal.com"), Skeleton("paypa1.com"))
+	assert.Equal(t, Skeleton("apple.com"), Skeleton("аpple.com"))
+	assert.Equal(t, Skeleton("apple.com"), Skeleton("аррӏе.com"))
+	assert.Equal(t, Skeleton("microsoft.com"), Skeleton("rnicrosoft.com"))
+	assert.Equal(t, Skeleton("google.com"), Skeleton("GOOGLE.com"))
+	assert.NotEqual(t, Skeleton("apple.com"), Skeleton("apply.com"))
+}
+
+func TestCheckSpoofing(t *testing.T) {
+	t.Parallel()
+
+	mb := mustMailbox(t, "PayPal", "service", "paypa1.com")
+	fs := CheckSpoofing(mb)
+	assert.Equal(t, []SpoofFinding{{
+		Kind:      SpoofConfusableDomain,
+		Part:      PartDomain,
+		Text:      "paypa1.com",
+		Level:     ASCIIOnly,
+		Protected: "paypal.com",
+	}}, fs)
+
+	mb = mustMailbox(t, "", "id", "аpple.com")
+	fs = CheckSpoofing(mb)
+	assert.Equal(t, []SpoofKind{SpoofMixedScript, SpoofConfusableDomain}, spoofKinds(fs))
+	assert.Equal(t, MinimallyRestrictive, fs[0].Level)
+	assert.Equal(t, "apple.com", fs[1].Protected)
+
+	// the punycode form is checked the same way
+	mb = mustMailbox(t, "", "id", "xn--pple-43d.com")
+	assert.Equal(t, []SpoofKind{SpoofMixedScript, SpoofConfusableDomain}, spoofKinds(CheckSpoofing(mb)))
+
+	// an imitation at a subdomain of the registrable domain
+	mb = mustMailbox(t, "", "id", "login.g00gle.com")
+	fs = CheckSpoofing(mb)
+	if assert.Len(t, fs, 1) {
+		assert.Equal(t, "google.com", fs[0].Protected)
+	}
+
+	mb, err := ParseEmailMailbox("=?UTF-8?Q?Support=E2=80=AEgnp.exe?= <support@example.com>")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []SpoofKind{SpoofBidiControl}, spoofKinds(CheckSpoofing(mb)))
+	}
+
+	mb = mustMailbox(t, "Support\u200f", "support", "example.com")
+	assert.Equal(t, []SpoofKind{SpoofBidiControl}, spoofKinds(CheckSpoofing(mb)))
+
+	mb = mustMailbox(t, "", "jоhn", "example.com")
+	fs = CheckSpoofing(mb)
+	if assert.Len(t, fs, 1) {
+		assert.Equal(t, PartLocalPart, fs[0].Part)
+	}
+
+	for _, d := range []string{"paypal.com", "www.apple.com", "bücher.example", "[192.0.2.1]"} {
+		assert.Empty(t, CheckSpoofing(mustMailbox(t, "", "john", d)), d)
+	}
+}
+
+func TestSpoofCheckerProtect(t *testing.T) {
+	t.Parallel()
+
+	c := NewSpoofChecker()
+	mb := mustMailbox(t, "", "ceo", "examp1e.com")
+	assert.Empty(t, c.Check(mb))
+
+	c.Protect("example.com")
+	fs := c.Check(mb)
+	if assert.Len(t, fs, 1) {
+		assert.Equal(t, "example.com", fs[0].Protected)
+	}
+
+	c.MaxLevel = MinimallyRestrictive
+	assert.Empty(t, c.Check(mustMailbox(t, "", "jоhn", "example.com")))
+}
+
+func TestSpoofCheckerProtectSameSkeleton(t *testing.T) {
+	t.Parallel()
+
+	c := NewSpoofChecker("rn.com", "m.com")
+	assert.Equal(t, Skeleton("rn.com"), Skeleton("m.com"))
+
+	assert.Empty(t, c.Check(mustMailbox(t, "", "john", "rn.com")))
+	assert.Empty(t, c.Check(mustMailbox(t, "", "john", "m.com")))
+	assert.Empty(t, c.Check(mustMailbox(t, "", "john", "mail.rn.com")))
+
+	fs := c.Check(mustMailbox(t, "", "john", "м.com"))
+	if assert.Len(t, fs, 1) {
+		assert.Equal(t, SpoofConfusableDomain, fs[0].Kind)
+		assert.Equal(t, "m.com", fs[0].Protected)
+	}
+}
+
+func TestSpoofCheckerZero(t *testing.T) {
+	t.Parallel()
+
+	var c SpoofChecker
+	assert.Empty(t, c.Check(mustMailbox(t, "", "ceo", "examp1e.com")))
+	assert.Equal(t, []SpoofKind{SpoofMixedScript}, spoofKinds(c.Check(mustMailbox(t, "", "jörg", "example.com"))))
+
+	c.Protect("example.com")
+	fs := c.Check(mustMailbox(t, "", "ceo", "examp1e.com"))
+	if assert.Len(t, fs, 1) {
+		assert.Equal(t, "example.com", fs[0].Protected)
+	}
+}
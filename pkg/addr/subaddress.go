@@ -0,0 +1,59 @@
+package addr
+
+import "strings"
+
+// DefaultDetailSeparator is the most common separator between the user and
+// the detail of a subaddress, as in "support+TICKET-123@example.com".
+const DefaultDetailSeparator = "+"
+
+// Subaddress splits the local part into the user and detail parts of an RFC
+// 5233 subaddress at the first occurrence of the separator, which is usually
+// "+" or "-". The split is made on the local part after any quoting has been
+// removed, so "\"support+a b\"@example.com" has the detail "a b".
+//
+// Following the Sieve subaddress extension, the detail is missing when the
+// local part does not contain the separator, which is reported by returning
+// false, and the detail is empty when the separator is the last thing in the
+// local part. In either case, the user is everything before the separator. If
+// the separator is the empty string or the local part starts with it, the
+// detail is missing, so the user is never empty.
+func (as *AddrSpec) Subaddress(sep string) (user, detail string, hasDetail bool) {
+	if sep == "" {
+		return as.localPart, "", false
+	}
+
+	i := strings.Index(as.localPart, sep)
+	if i <= 0 {
+		return as.localPart, "", false
+	}
+
+	return as.localPart[:i], as.localPart[i+len(sep):], true
+}
+
+// User returns the user part of the subaddress. See Subaddress.
+func (as *AddrSpec) User(sep string) string {
+	u, _, _ := as.Subaddress(sep)
+	return u
+}
+
+// Detail returns the detail part of the subaddress and whether the detail is
+// present at all. See Subaddress.
+func (as *AddrSpec) Detail(sep string) (string, bool) {
+	_, d, ok := as.Subaddress(sep)
+	return d, ok
+}
+
+// WithDetail returns a new AddrSpec at the same domain with a local part made
+// from the user part of this address, the separator, and the given detail. Any
+// detail already present is replaced. The detail may be empty, which results
+// in a local part ending with the separator. The local part will be quoted as
+// needed when rendered, e.g., when the detail contains a space.
+func (as *AddrSpec) WithDetail(sep, detail string) *AddrSpec {
+	return NewAddrSpec(as.User(sep)+sep+detail, as.domain)
+}
+
+// WithoutDetail returns a new AddrSpec at the same domain with only the user
+// part of this address as the local part.
+func (as *AddrSpec) WithoutDetail(sep string) *AddrSpec {
+	return NewAddrSpec(as.User(sep), as.domain)
+}
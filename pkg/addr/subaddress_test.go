@@ -0,0 +1,62 @@
+package addr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubaddress(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in, sep      string
+		user, detail string
+		hasDetail    bool
+	}{
+		{"support+TICKET-123@example.com", "+", "support", "TICKET-123", true},
+		{"support+TICKET-123@example.com", "-", "support+TICKET", "123", true},
+		{"support+@example.com", "+", "support", "", true},
+		{"support@example.com", "+", "support", "", false},
+		{"a+b+c@example.com", "+", "a", "b+c", true},
+		{"a--b@example.com", "--", "a", "b", true},
+		{`"support+a b"@example.com`, "+", "support", "a b", true},
+		{"support+x@example.com", "", "support+x", "", false},
+		{"+a@example.com", "+", "+a", "", false},
+		{"+@example.com", "+", "+", "", false},
+	}
+
+	for _, tc := range tests {
+		as, err := ParseEmailAddrSpec(tc.in)
+		if !assert.NoError(t, err, tc.in) {
+			continue
+		}
+
+		u, d, ok := as.Subaddress(tc.sep)
+		assert.Equal(t, tc.user, u, tc.in)
+		assert.Equal(t, tc.detail, d, tc.in)
+		assert.Equal(t, tc.hasDetail, ok, tc.in)
+
+		assert.Equal(t, tc.user, as.User(tc.sep), tc.in)
+		d, ok = as.Detail(tc.sep)
+		assert.Equal(t, tc.detail, d, tc.in)
+		assert.Equal(t, tc.hasDetail, ok, tc.in)
+	}
+}
+
+func TestWithDetail(t *testing.T) {
+	t.Parallel()
+
+	as := NewAddrSpec("support", "example.com")
+
+	tagged := as.WithDetail(DefaultDetailSeparator, "TICKET-123")
+	assert.Equal(t, "support+TICKET-123@example.com", tagged.CleanString())
+
+	assert.Equal(t, "support+TICKET-456@example.com", tagged.WithDetail("+", "TICKET-456").CleanString())
+	assert.Equal(t, `"support+a b"@example.com`, as.WithDetail("+", "a b").CleanString())
+	assert.Equal(t, "support+@example.com", as.WithDetail("+", "").CleanString())
+	assert.Equal(t, "support@example.com", tagged.WithoutDetail("+").CleanString())
+
+	// the original is not modified
+	assert.Equal(t, "support@example.com", as.CleanString())
+}
@@ -0,0 +1,291 @@
+package addr
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// These are the costs of the edits used to measure the distance between a
+// domain and a suggestion.
+const (
+	editCost          = 1.0 // insert, delete, or substitute a character
+	adjacentKeyCost   = 0.5 // substitute a character for a neighboring key
+	transpositionCost = 0.5 // swap two neighboring characters
+
+	// tldOnlyCost is added to the distance of a suggestion that only corrects
+	// the top-level domain, since the rest of the domain is not known to be
+	// correct, so that a popular domain at the same distance ranks first.
+	tldOnlyCost = 0.75
+)
+
+// DefaultMaxSuggestionDistance is the largest edit distance at which a
+// DomainSuggester makes a suggestion by default.
+const DefaultMaxSuggestionDistance = 2.0
+
+// builtinSuggestionDomains are the popular mail domains each new
+// DomainSuggester starts with.
+var builtinSuggestionDomains = []string{
+	"aol.com", "att.net", "comcast.net", "fastmail.com", "gmail.com",
+	"gmx.com", "gmx.de", "googlemail.com", "hotmail.co.uk", "hotmail.com",
+	"hotmail.fr", "icloud.com", "live.com", "mac.com", "mail.com", "me.com",
+	"msn.com", "outlook.com", "proton.me", "protonmail.com", "qq.com",
+	"sbcglobal.net", "verizon.net", "web.de", "yahoo.co.uk", "yahoo.com",
+	"yahoo.fr", "yandex.ru", "zoho.com",
+}
+
+// builtinSuggestionTLDs are the popular top-level domains each new
+// DomainSuggester starts with.
+var builtinSuggestionTLDs = []string{
+	"at", "au", "be", "biz", "br", "ca", "ch", "co", "co.uk", "com", "de",
+	"dk", "edu", "es", "eu", "fr", "gov", "info", "io", "it", "jp", "me",
+	"net", "nl", "no", "org", "pl", "ru", "se", "uk", "us",
+}
+
+// keyboardRows is the layout of a QWERTY keyboard. Each row is offset half a
+// key to the right of the row above it.
+var keyboardRows = []string{
+	"1234567890-",
+	"qwertyuiop",
+	"asdfghjkl",
+	"zxcvbnm",
+}
+
+// keyboardPositions maps each key to its row and column.
+var keyboardPositions = func() map[rune][2]int {
+	pos := map[rune][2]int{}
+	for r, row := range keyboardRows {
+		for c, k := range row {
+			pos[k] = [2]int{r, c}
+		}
+	}
+	return pos
+}()
+
+// adjacentKeys returns true if the keys are next to each other on a QWERTY
+// keyboard.
+func adjacentKeys(a, b rune) bool {
+	pa, oka := keyboardPositions[a]
+	pb, okb := keyboardPositions[b]
+	if !oka || !okb {
+		return false
+	}
+
+	dr, dc := pb[0]-pa[0], pb[1]-pa[1]
+	switch dr {
+	case 0:
+		return dc == -1 || dc == 1
+	case 1:
+		return dc == -1 || dc == 0
+	case -1:
+		return dc == 0 || dc == 1
+	default:
+		return false
+	}
+}
+
+// typoDistance returns the edit distance between the strings, in which typing
+// a neighboring key and swapping two characters cost less than other edits.
+func typoDistance(s, t string) float64 {
+	a, b := []rune(s), []rune(t)
+
+	d := make([][]float64, len(a)+1)
+	for i := range d {
+		d[i] = make([]float64, len(b)+1)
+		d[i][0] = float64(i) * editCost
+	}
+	for j := range d[0] {
+		d[0][j] = float64(j) * editCost
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			sub := editCost
+			switch {
+			case a[i-1] == b[j-1]:
+				sub = 0
+			case adjacentKeys(a[i-1], b[j-1]):
+				sub = adjacentKeyCost
+			}
+
+			best := d[i-1][j-1] + sub
+			if v := d[i-1][j] + editCost; v < best {
+				best = v
+			}
+			if v := d[i][j-1] + editCost; v < best {
+				best = v
+			}
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				if v := d[i-2][j-2] + transpositionCost; v < best {
+					best = v
+				}
+			}
+
+			d[i][j] = best
+		}
+	}
+
+	return d[len(a)][len(b)]
+}
+
+// Suggestion is a proposed correction of the domain of an address.
+type Suggestion struct {
+	AddrSpec   *AddrSpec // the address with the suggested domain
+	Domain     string    // the suggested domain
+	Distance   float64   // the edit distance from the original domain
+	Confidence float64   // from 0 to 1, how likely the suggestion is correct
+}
+
+// DomainSuggester proposes corrections of mistyped domains by comparing them
+// with lists of popular domains and top-level domains. It is safe for
+// concurrent use.
+type DomainSuggester struct {
+	// MaxDistance is the largest edit distance at which a suggestion is made.
+	MaxDistance float64
+
+	lock    sync.RWMutex
+	domains map[string]struct{}
+	tlds    map[string]struct{}
+}
+
+// DefaultDomainSuggester is the suggester used by SuggestDomain. It starts
+// with the built-in lists, which may be replaced at any time.
+var DefaultDomainSuggester = NewDomainSuggester()
+
+// NewDomainSuggester returns a suggester that starts with the built-in lists
+// of popular domains and top-level domains.
+func NewDomainSuggester() *DomainSuggester {
+	s := &DomainSuggester{MaxDistance: DefaultMaxSuggestionDistance}
+	s.SetDomains(builtinSuggestionDomains)
+	s.SetTLDs(builtinSuggestionTLDs)
+	return s
+}
+
+// suggestionKey returns the form of a domain used for comparison, which is the
+// lowercase Unicode form so that internationalized domains are compared
+// character by character.
+func suggestionKey(d string) string {
+	d = domainKey(d)
+	if u, err := idnaProfile.ToUnicode(d); err == nil {
+		d = u
+	}
+	return strings.TrimSuffix(d, ".")
+}
+
+// suggestionSet returns the list as a set of suggestion keys.
+func suggestionSet(ds []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(ds))
+	for _, d := range ds {
+		set[suggestionKey(d)] = struct{}{}
+	}
+	return set
+}
+
+// SetDomains replaces the list of popular domains.
+func (s *DomainSuggester) SetDomains(ds []string) {
+	set := suggestionSet(ds)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.domains = set
+}
+
+// SetTLDs replaces the list of popular top-level domains, which may have more
+// than one label, e.g., "co.uk".
+func (s *DomainSuggester) SetTLDs(tlds []string) {
+	set := suggestionSet(tlds)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.tlds = set
+}
+
+// knownTLD returns the longest popular TLD the domain ends with.
+func (s *DomainSuggester) knownTLD(d string) (string, bool) {
+	labels := strings.Split(d, ".")
+	for i := 1; i < len(labels); i++ {
+		tld := strings.Join(labels[i:], ".")
+		if _, ok := s.tlds[tld]; ok {
+			return tld, true
+		}
+	}
+	return "", false
+}
+
+// Suggest returns the suggested corrections of the domain of the address,
+// with the most likely first. The address itself is never changed; each
+// suggestion holds a new AddrSpec with the same local part. No suggestions are
+// returned when the domain is one of the popular domains, when it is a domain
+// literal, or when nothing is close enough.
+//
+// The domain is compared with each of the popular domains. If it does not
+// end with a popular top-level domain, its last label is also compared with
+// each of those, but such suggestions rank below a popular domain at the same
+// distance. Suggested domains are in lowercase Unicode form.
+func (s *DomainSuggester) Suggest(as *AddrSpec) []Suggestion {
+	if strings.HasPrefix(as.domain, "[") {
+		return nil
+	}
+
+	d := suggestionKey(as.domain)
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if _, ok := s.domains[d]; ok {
+		return nil
+	}
+
+	found := map[string]float64{}
+	consider := func(cand string, dist float64) {
+		if dist == 0 || dist > s.MaxDistance || dist*3 > float64(len([]rune(cand))) {
+			return
+		}
+		if old, ok := found[cand]; !ok || dist < old {
+			found[cand] = dist
+		}
+	}
+
+	for cand := range s.domains {
+		consider(cand, typoDistance(d, cand))
+	}
+
+	if _, ok := s.knownTLD(d); !ok {
+		if i := strings.LastIndex(d, "."); i > 0 {
+			name, tld := d[:i], d[i+1:]
+			for cand := range s.tlds {
+				if !strings.Contains(cand, ".") {
+					dist := typoDistance(tld, cand)
+					if dist*2 <= float64(len(cand)) {
+						consider(name+"."+cand, dist+tldOnlyCost)
+					}
+				}
+			}
+		}
+	}
+
+	ss := make([]Suggestion, 0, len(found))
+	for cand, dist := range found {
+		ss = append(ss, Suggestion{
+			AddrSpec:   NewAddrSpec(as.localPart, cand),
+			Domain:     cand,
+			Distance:   dist,
+			Confidence: 1 - dist/(s.MaxDistance+1),
+		})
+	}
+
+	sort.Slice(ss, func(i, j int) bool {
+		if ss[i].Distance != ss[j].Distance {
+			return ss[i].Distance < ss[j].Distance
+		}
+		return ss[i].Domain < ss[j].Domain
+	})
+
+	return ss
+}
+
+// SuggestDomain returns suggested corrections of the domain of the address
+// using DefaultDomainSuggester. See DomainSuggester.Suggest.
+func SuggestDomain(as *AddrSpec) []Suggestion {
+	return DefaultDomainSuggester.Suggest(as)
+}
@@ -0,0 +1,71 @@
+package addr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypoDistance(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 0.0, typoDistance("gmail.com", "gmail.com"))
+	assert.Equal(t, 0.5, typoDistance("gmial.com", "gmail.com"))
+	assert.Equal(t, 0.5, typoDistance("gnail.com", "gmail.com"))
+	assert.Equal(t, 1.0, typoDistance("gzail.com", "gmail.com"))
+	assert.Equal(t, 1.0, typoDistance("gmai.com", "gmail.com"))
+	assert.Equal(t, 1.0, typoDistance("bücher", "bucher"))
+}
+
+func TestSuggestDomain(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		domain string
+		first  string
+	}{
+		{"gmial.com", "gmail.com"},
+		{"hotmial.con", "hotmail.com"},
+		{"yahoo.co", "yahoo.com"},
+		{"outlok.com", "outlook.com"},
+		{"example.con", "example.com"},
+		{"GMIAL.COM", "gmail.com"},
+	}
+
+	for _, tc := range tests {
+		as := NewAddrSpec("john", tc.domain)
+		ss := SuggestDomain(as)
+		if !assert.NotEmpty(t, ss, tc.domain) {
+			continue
+		}
+
+		assert.Equal(t, tc.first, ss[0].Domain, tc.domain)
+		assert.Equal(t, "john@"+tc.first, ss[0].AddrSpec.CleanString(), tc.domain)
+		assert.Equal(t, tc.domain, as.Domain(), tc.domain)
+
+		for i := 1; i < len(ss); i++ {
+			assert.True(t, ss[i-1].Confidence >= ss[i].Confidence, tc.domain)
+		}
+	}
+
+	for _, d := range []string{"gmail.com", "example.org", "[192.0.2.1]", "corp.example.co.uk"} {
+		assert.Empty(t, SuggestDomain(NewAddrSpec("john", d)), d)
+	}
+
+	ss := SuggestDomain(NewAddrSpec("john", "gmial.com"))
+	assert.Equal(t, 0.5, ss[0].Distance)
+	assert.InDelta(t, 0.83, ss[0].Confidence, 0.01)
+}
+
+func TestDomainSuggesterIDN(t *testing.T) {
+	t.Parallel()
+
+	s := NewDomainSuggester()
+	s.SetDomains([]string{"bücher.example", "xn--mnchen-3ya.example"})
+	s.SetTLDs([]string{"example"})
+
+	assert.Equal(t, []string{"bücher.example"}, suggestedDomains(s.Suggest(NewAddrSpec("john", "bücherr.example"))))
+	assert.Equal(t, []string{"münchen.example"}, suggestedDomains(s.Suggest(NewAddrSpec("john", "munchen.example"))))
+	assert.Empty(t, s.Suggest(NewAddrSpec("john", "xn--bcher-kva.example")))
+	assert.Empty(t, s.Suggest(NewAddrSpec("john", "gmial.com")))
+}
@@ -0,0 +1,218 @@
+package addr
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zostay/go-addr/pkg/format"
+)
+
+// These are the length limits of RFC 5321 section 4.5.3.1, in octets.
+const (
+	MaxLocalPartLength = 64
+	MaxDomainLength    = 255
+	MaxPathLength      = 256
+	MaxLabelLength     = 63
+)
+
+// validateFieldName is the header field name used when checking the line length
+// limit of a rendered address.
+const validateFieldName = "To"
+
+// These are the kinds of violation reported by Validate.
+const (
+	ViolationLocalPartLength = "local part too long"
+	ViolationDomainLength    = "domain too long"
+	ViolationPathLength      = "path too long"
+	ViolationLabelLength     = "label too long"
+	ViolationLabelSyntax     = "invalid label"
+	ViolationIDNA            = "invalid internationalized domain"
+	ViolationLineLength      = "header line too long"
+)
+
+// Violation describes a single way in which an address breaks the limits of
+// RFC 5321 or RFC 5322.
+type Violation struct {
+	Kind   string // the kind of violation, e.g., ViolationLabelLength
+	Part   string // the part of the address that is in violation
+	Length int    // the length of the part in octets, if the kind is a length
+	Limit  int    // the limit that was exceeded, if the kind is a length
+}
+
+// String returns a message describing the violation.
+func (v Violation) String() string {
+	if v.Limit > 0 {
+		return fmt.Sprintf("%s: %q is %d octets, limit is %d", v.Kind, v.Part, v.Length, v.Limit)
+	}
+
+	return fmt.Sprintf("%s: %q", v.Kind, v.Part)
+}
+
+// ValidationError is returned by Validate and lists every violation found.
+type ValidationError struct {
+	Violations []Violation
+}
+
+// Error returns a message listing every violation.
+func (e *ValidationError) Error() string {
+	ms := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		ms[i] = v.String()
+	}
+	return strings.Join(ms, "; ")
+}
+
+// validationError returns a *ValidationError listing the violations or nil if
+// there are none.
+func validationError(vs []Violation) error {
+	if len(vs) == 0 {
+		return nil
+	}
+
+	return &ValidationError{vs}
+}
+
+// lengthViolation returns the violation in a slice if the part exceeds the
+// limit.
+func lengthViolation(kind, part string, limit int) []Violation {
+	if len(part) <= limit {
+		return nil
+	}
+
+	return []Violation{{kind, part, len(part), limit}}
+}
+
+// isLDHLabel returns true if the label contains only letters, digits, and
+// hyphens and neither starts nor ends with a hyphen.
+func isLDHLabel(l string) bool {
+	if l == "" || l[0] == '-' || l[len(l)-1] == '-' {
+		return false
+	}
+
+	for _, c := range []byte(l) {
+		if !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') && c != '-' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isIDNA returns true if the domain is internationalized, i.e., it contains
+// characters outside of US-ASCII or a punycode label. Other domains that fail
+// IDNA conversion are reported by the label checks instead.
+func isIDNA(d string) bool {
+	if strings.IndexFunc(d, func(c rune) bool { return c > '~' }) > -1 {
+		return true
+	}
+
+	for _, l := range strings.Split(strings.ToLower(d), ".") {
+		if strings.HasPrefix(l, "xn--") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// path returns the address as an SMTP path as described in RFC 5321 section
+// 4.1.2, i.e., in angle brackets with the domain in ASCII form.
+func (as *AddrSpec) path() string {
+	d := as.domain
+	if !strings.HasPrefix(d, "[") {
+		if ad, err := canonicalDomain(d); err == nil {
+			d = ad
+		}
+	}
+
+	return "<" + format.RenderAddrSpec(as.localPart, d) + ">"
+}
+
+// violations returns every violation of the address.
+func (as *AddrSpec) violations() []Violation {
+	var vs []Violation
+
+	lp := format.RenderLocalPart(as.localPart)
+	vs = append(vs, lengthViolation(ViolationLocalPartLength, lp, MaxLocalPartLength)...)
+
+	d := as.domain
+	if !strings.HasPrefix(d, "[") {
+		if ad, err := canonicalDomain(d); err == nil {
+			d = ad
+		} else if isIDNA(d) {
+			vs = append(vs, Violation{Kind: ViolationIDNA, Part: d})
+		}
+
+		for _, l := range strings.Split(d, ".") {
+			vs = append(vs, lengthViolation(ViolationLabelLength, l, MaxLabelLength)...)
+			if !isLDHLabel(l) {
+				vs = append(vs, Violation{Kind: ViolationLabelSyntax, Part: l})
+			}
+		}
+	}
+
+	vs = append(vs, lengthViolation(ViolationDomainLength, d, MaxDomainLength)...)
+
+	vs = append(vs, lengthViolation(ViolationPathLength, as.path(), MaxPathLength)...)
+
+	return vs
+}
+
+// lineViolations returns a violation if the items cannot be folded into a
+// header within the line length limit.
+func lineViolations(items []string) []Violation {
+	n := format.LongestFoldedLine(validateFieldName, items)
+	if n <= format.LineLengthHardLimit {
+		return nil
+	}
+
+	return []Violation{{
+		Kind:   ViolationLineLength,
+		Part:   strings.Join(items, ", "),
+		Length: n,
+		Limit:  format.LineLengthHardLimit,
+	}}
+}
+
+// Validate checks the address against the limits of RFC 5321: the local part
+// may be at most 64 octets, the domain at most 255 octets, each label of the
+// domain at most 63 octets, and the address in angle brackets (the SMTP path)
+// at most 256 octets. Each label of the domain must also be made up of
+// letters, digits, and hyphens without a leading or trailing hyphen.
+// Internationalized domains are checked in their ASCII form. Domain literals
+// are only checked for length.
+//
+// If anything is wrong, a *ValidationError listing every violation is
+// returned.
+func (as *AddrSpec) Validate() error {
+	return validationError(as.violations())
+}
+
+// Validate checks the AddrSpec of the mailbox as described for
+// AddrSpec.Validate. It also checks that the mailbox can be rendered in a "To"
+// header without any line exceeding the limit of 998 octets.
+func (m *Mailbox) Validate() error {
+	vs := m.address.violations()
+	vs = append(vs, lineViolations([]string{m.CleanString()})...)
+	return validationError(vs)
+}
+
+// Validate checks every mailbox of the list as described for
+// AddrSpec.Validate, including the mailboxes within groups. It also checks
+// that the whole list can be rendered in a "To" header without any line
+// exceeding the limit of 998 octets.
+func (as AddressList) Validate() error {
+	var vs []Violation
+	items := make([]string, len(as))
+	for i, a := range as {
+		for _, mb := range (AddressList{a}).Flatten() {
+			if mb != nil {
+				vs = append(vs, mb.address.violations()...)
+			}
+		}
+		items[i] = a.CleanString()
+	}
+
+	vs = append(vs, lineViolations(items)...)
+	return validationError(vs)
+}
@@ -0,0 +1,74 @@
+package addr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddrSpecValidate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, NewAddrSpec("john", "example.com").Validate())
+	assert.NoError(t, NewAddrSpec("john", "bücher.example").Validate())
+	assert.NoError(t, NewAddrSpec("john", "[192.0.2.1]").Validate())
+	assert.NoError(t, NewAddrSpec(strings.Repeat("a", 64), "example.com").Validate())
+
+	err := NewAddrSpec(strings.Repeat("a", 65), "example.com").Validate()
+	assert.Equal(t, []string{ViolationLocalPartLength}, violationKinds(t, err))
+
+	var ve *ValidationError
+	if assert.True(t, errors.As(err, &ve)) {
+		assert.Equal(t, Violation{ViolationLocalPartLength, strings.Repeat("a", 65), 65, 64}, ve.Violations[0])
+	}
+
+	err = NewAddrSpec("john", strings.Repeat("a", 70)+".example").Validate()
+	assert.Equal(t, []string{ViolationLabelLength}, violationKinds(t, err))
+
+	err = NewAddrSpec("john", "-bad.under_score.example").Validate()
+	assert.Equal(t, []string{ViolationLabelSyntax, ViolationLabelSyntax}, violationKinds(t, err))
+	assert.Equal(t, `invalid label: "-bad"; invalid label: "under_score"`, err.Error())
+
+	err = NewAddrSpec("john", "xn--a.example").Validate()
+	assert.Equal(t, []string{ViolationIDNA}, violationKinds(t, err))
+
+	label := strings.Repeat("a", 63)
+	long := strings.Repeat(label+".", 4) + "example"
+	err = NewAddrSpec(strings.Repeat("a", 65), long).Validate()
+	assert.Equal(t, []string{
+		ViolationLocalPartLength,
+		ViolationDomainLength,
+		ViolationPathLength,
+	}, violationKinds(t, err))
+}
+
+func TestMailboxValidate(t *testing.T) {
+	t.Parallel()
+
+	mb, err := NewMailboxStr("John Smith", "john@example.com", "")
+	assert.NoError(t, err)
+	assert.NoError(t, mb.Validate())
+
+	// a long name may be folded, so it is fine
+	mb, err = NewMailboxStr(strings.Repeat("John Smith ", 200), "john@example.com", "")
+	assert.NoError(t, err)
+	assert.NoError(t, mb.Validate())
+
+	mb, err = NewMailboxStr(strings.Repeat("x", 1000), "john@example.com", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{ViolationLineLength}, violationKinds(t, mb.Validate()))
+}
+
+func TestAddressListValidate(t *testing.T) {
+	t.Parallel()
+
+	al := mustParseList(t, "ok@example.com, Team: bad@-example.com, ok@example.com;, "+
+		strings.Repeat("a", 70)+"@example.com")
+
+	err := al.Validate()
+	assert.Equal(t, []string{ViolationLabelSyntax, ViolationLocalPartLength}, violationKinds(t, err))
+
+	assert.NoError(t, mustParseList(t, "a@example.com, b@example.com").Validate())
+}
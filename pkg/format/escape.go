@@ -10,6 +10,10 @@ import (
 
 // IsAText return true if the given rune matches rfc5322.MatchAText.
 func IsAText(c rune) bool {
+	if c > '~' {
+		return false
+	}
+
 	m, _ := rfc5322.MatchAText([]byte{byte(c)})
 	return m != nil
 }
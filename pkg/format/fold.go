@@ -0,0 +1,121 @@
+package format
+
+import (
+	"errors"
+	"strings"
+)
+
+// These are the line length limits from RFC 5322 section 2.1.1. Neither limit
+// includes the CRLF that ends the line.
+const (
+	// LineLengthLimit is the recommended maximum length of a header line.
+	LineLengthLimit = 78
+
+	// LineLengthHardLimit is the absolute maximum length of a header line.
+	LineLengthHardLimit = 998
+)
+
+// ErrLineTooLong is returned by FoldList when some part of the header cannot
+// be folded to fit within LineLengthHardLimit.
+var ErrLineTooLong = errors.New("header line exceeds the 998 octet limit")
+
+// FoldList renders a header field with the given name whose body is the list
+// of items separated by commas. The items must already be correctly quoted or
+// encoded for use in a header (e.g., the output of an address CleanString).
+//
+// The header is folded so that lines fit within LineLengthLimit whenever
+// possible. Folding happens between list items first. If an item is too long
+// to fit on a line by itself, it is folded at whitespace within the item
+// instead, which is how long phrases and runs of MIME encoded words get split.
+//
+// Lines are separated with CRLF. The returned string does not include the
+// CRLF that ends the final line.
+//
+// If any line of the result would exceed LineLengthHardLimit, ErrLineTooLong
+// is returned.
+func FoldList(name string, items []string) (string, error) {
+	lines := foldLines(name, items)
+	for _, l := range lines {
+		if len(l) > LineLengthHardLimit {
+			return "", ErrLineTooLong
+		}
+	}
+
+	return strings.Join(lines, "\r\n"), nil
+}
+
+// LongestFoldedLine returns the length of the longest line of the header that
+// FoldList would produce for the same arguments. Unlike FoldList, this is
+// returned even when the length exceeds LineLengthHardLimit.
+func LongestFoldedLine(name string, items []string) int {
+	longest := 0
+	for _, l := range foldLines(name, items) {
+		if len(l) > longest {
+			longest = len(l)
+		}
+	}
+	return longest
+}
+
+// foldLines returns the lines of the folded header built by FoldList.
+func foldLines(name string, items []string) []string {
+	lines := make([]string, 0, 1)
+	line := name + ":"
+	fresh := true
+
+	fold := func(piece string) {
+		lines = append(lines, line)
+		line = piece
+		fresh = true
+	}
+
+	for i, item := range items {
+		if i < len(items)-1 {
+			item += ","
+		}
+
+		if len(line)+1+len(item) <= LineLengthLimit {
+			line += " " + item
+			fresh = false
+			continue
+		}
+
+		if !fresh && 1+len(item) <= LineLengthLimit {
+			fold(" " + item)
+			fresh = false
+			continue
+		}
+
+		for _, w := range splitFoldable(item) {
+			piece := " " + w
+			if !fresh && len(line)+len(piece) > LineLengthLimit {
+				fold(piece)
+			} else {
+				line += piece
+			}
+			fresh = false
+		}
+	}
+
+	return append(lines, line)
+}
+
+// splitFoldable splits the string at each space where it is safe to insert a
+// fold. The space at each split point is dropped as it is restored by the
+// leading whitespace of the continuation line. A split is never made where it
+// would leave a line containing only whitespace or where it would break apart
+// a quoted pair.
+func splitFoldable(s string) []string {
+	ws := make([]string, 0, 1)
+	start := 0
+	for i := 1; i < len(s)-1; i++ {
+		if s[i] != ' ' || i == start || s[i+1] == ' ' || s[i-1] == '\\' {
+			continue
+		}
+
+		ws = append(ws, s[start:i])
+		start = i + 1
+	}
+
+	return append(ws, s[start:])
+}
@@ -0,0 +1,88 @@
+package format
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFoldListShort(t *testing.T) {
+	t.Parallel()
+
+	h, err := FoldList("To", []string{"a@example.com", "b@example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, "To: a@example.com, b@example.com", h)
+}
+
+func TestFoldListEmpty(t *testing.T) {
+	t.Parallel()
+
+	h, err := FoldList("Bcc", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bcc:", h)
+}
+
+func TestFoldListAtSeparators(t *testing.T) {
+	t.Parallel()
+
+	items := make([]string, 20)
+	for i := range items {
+		items[i] = "\"Some Person\" <some.person@example.com>"
+	}
+
+	h, err := FoldList("To", items)
+	assert.NoError(t, err)
+
+	lines := strings.Split(h, "\r\n")
+	assert.Len(t, lines, 20)
+	for i, l := range lines {
+		assert.LessOrEqual(t, len(l), LineLengthLimit)
+		if i > 0 {
+			assert.True(t, strings.HasPrefix(l, " \"Some Person\""))
+		}
+	}
+
+	assert.Equal(t, "To: "+strings.Join(items, ", "), strings.ReplaceAll(h, "\r\n", ""))
+}
+
+func TestFoldListInsidePhrase(t *testing.T) {
+	t.Parallel()
+
+	long := "\"" + strings.Repeat("word ", 30) + "end\" <x@example.com>"
+	h, err := FoldList("To", []string{long})
+	assert.NoError(t, err)
+
+	for _, l := range strings.Split(h, "\r\n") {
+		assert.LessOrEqual(t, len(l), LineLengthLimit)
+		assert.NotEmpty(t, strings.TrimSpace(l))
+	}
+
+	assert.Equal(t, "To: "+long, strings.ReplaceAll(h, "\r\n", ""))
+}
+
+func TestFoldListTooLong(t *testing.T) {
+	t.Parallel()
+
+	_, err := FoldList("To", []string{strings.Repeat("x", 1000) + "@example.com"})
+	assert.Equal(t, ErrLineTooLong, err)
+}
+
+func TestSplitFoldable(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"a", "b"}, splitFoldable("a b"))
+	assert.Equal(t, []string{"a ", "b"}, splitFoldable("a  b"))
+	assert.Equal(t, []string{"\"a\\ b\""}, splitFoldable("\"a\\ b\""))
+	assert.Equal(t, []string{" a"}, splitFoldable(" a"))
+}
+
+func TestLongestFoldedLine(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 32, LongestFoldedLine("To", []string{"a@example.com", "b@example.com"}))
+
+	long := strings.Repeat("x", 1200) + "@example.com"
+	assert.Equal(t, 1216, LongestFoldedLine("To", []string{long}))
+	assert.Equal(t, 1213, LongestFoldedLine("To", []string{"a@example.com", long}))
+}
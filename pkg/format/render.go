@@ -0,0 +1,215 @@
+package format
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// maxEncodedWordLength is the longest an RFC 2047 encoded word may be.
+const maxEncodedWordLength = 75
+
+// isQSafe returns true if the byte may appear as itself in the encoded text of
+// a Q-encoded word. The comment flag selects the rules for encoded words within
+// a comment rather than a phrase. See RFC 2047 section 5.
+func isQSafe(c byte, comment bool) bool {
+	if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') {
+		return true
+	}
+
+	if comment {
+		return c > ' ' && c <= '~' &&
+			c != '(' && c != ')' && c != '"' && c != '\\' &&
+			c != '=' && c != '?' && c != '_'
+	}
+
+	return c == '!' || c == '*' || c == '+' || c == '-' || c == '/'
+}
+
+// EncodeWords encodes the string as a series of UTF-8 Q-encoded words
+// separated by spaces. Every word is kept within the 75 octet limit for
+// encoded words and multi-byte characters are never split across words.
+//
+// If the comment option is set, the words are encoded for use within a
+// comment. Otherwise, they are encoded for use as a phrase (i.e., a display
+// name), which is more restrictive.
+func EncodeWords(s string, comment bool) string {
+	const (
+		prefix = "=?utf-8?q?"
+		suffix = "?="
+	)
+
+	var (
+		a    strings.Builder
+		word strings.Builder
+	)
+
+	flush := func() {
+		if a.Len() > 0 {
+			a.WriteRune(' ')
+		}
+		a.WriteString(prefix)
+		a.WriteString(word.String())
+		a.WriteString(suffix)
+		word.Reset()
+	}
+
+	var enc strings.Builder
+	for len(s) > 0 {
+		_, n := utf8.DecodeRuneInString(s)
+
+		enc.Reset()
+		for _, c := range []byte(s[:n]) {
+			switch {
+			case c == ' ':
+				enc.WriteByte('_')
+			case isQSafe(c, comment):
+				enc.WriteByte(c)
+			default:
+				enc.WriteByte('=')
+				enc.WriteByte("0123456789ABCDEF"[c>>4])
+				enc.WriteByte("0123456789ABCDEF"[c&0xf])
+			}
+		}
+		s = s[n:]
+
+		if word.Len() > 0 && len(prefix)+word.Len()+enc.Len()+len(suffix) > maxEncodedWordLength {
+			flush()
+		}
+		word.WriteString(enc.String())
+	}
+
+	if word.Len() > 0 || a.Len() == 0 {
+		flush()
+	}
+
+	return a.String()
+}
+
+// NeedsWordEncoding returns true if the string can only be represented in a
+// header using encoded words. This is true when it contains anything other
+// than printable ASCII, spaces, and tabs, or when it contains something that
+// would be mistaken for an encoded word when parsed.
+func NeedsWordEncoding(s string) bool {
+	if HasMIMEWord(s) {
+		return true
+	}
+
+	return strings.IndexFunc(s, func(c rune) bool {
+		return c != '\t' && (c < ' ' || c > '~')
+	}) > -1
+}
+
+// IsDotAtomText returns true if the string matches the dot-atom-text
+// production of RFC 5322.
+func IsDotAtomText(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, a := range strings.Split(s, ".") {
+		if a == "" || strings.IndexFunc(a, func(c rune) bool { return !IsAText(c) }) > -1 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// QuoteString returns the string as an RFC 5322 quoted-string, escaping all
+// characters that require it. Unlike MaybeEscape, the string is always quoted.
+func QuoteString(s string) string {
+	var a strings.Builder
+	a.WriteRune('"')
+	for _, c := range s {
+		if CharNeedsEscape(c) {
+			a.WriteRune('\\')
+		}
+		a.WriteRune(c)
+	}
+	a.WriteRune('"')
+	return a.String()
+}
+
+// RenderPhrase returns the given display name formatted as an RFC 5322 phrase.
+// The name is left as-is when it is made up only of atoms, it is quoted when it
+// contains specials or whitespace, and it is turned into a series of encoded
+// words when it contains characters that cannot be quoted. An empty name is
+// rendered as an empty quoted string.
+//
+// When the output is parsed and MIME words are decoded, the result will be
+// identical to the input.
+func RenderPhrase(s string) string {
+	if NeedsWordEncoding(s) {
+		return EncodeWords(s, false)
+	}
+
+	if s == "" {
+		return `""`
+	}
+
+	return MaybeEscape(s, true)
+}
+
+// RenderComment returns the given comment text formatted as an RFC 5322
+// comment, including the surrounding parentheses. Backslashes are escaped and
+// parentheses are escaped as well unless they are balanced. If the comment
+// contains characters that cannot be written in a comment, it is turned into a
+// series of encoded words instead.
+//
+// When the output is parsed and MIME words are decoded, the result will be
+// identical to the input.
+func RenderComment(s string) string {
+	if NeedsWordEncoding(s) {
+		return "(" + EncodeWords(s, true) + ")"
+	}
+
+	escParens := !balancedParens(s)
+
+	var a strings.Builder
+	a.WriteRune('(')
+	for _, c := range s {
+		if c == '\\' || (escParens && (c == '(' || c == ')')) {
+			a.WriteRune('\\')
+		}
+		a.WriteRune(c)
+	}
+	a.WriteRune(')')
+	return a.String()
+}
+
+// balancedParens returns true if every parenthesis in the string is matched.
+func balancedParens(s string) bool {
+	lp := 0
+	for _, c := range s {
+		if c == '(' {
+			lp++
+		} else if c == ')' {
+			lp--
+			if lp < 0 {
+				return false
+			}
+		}
+	}
+	return lp == 0
+}
+
+// RenderLocalPart returns the given local part formatted for use in an
+// addr-spec. It is left as-is if it is a valid dot-atom and it is quoted
+// otherwise.
+//
+// RFC 5322 provides no way to encode characters outside of US-ASCII in a local
+// part, so such characters will be output as-is and the result may not parse.
+func RenderLocalPart(s string) string {
+	if IsDotAtomText(s) {
+		return s
+	}
+
+	return QuoteString(s)
+}
+
+// RenderAddrSpec returns the local part and domain formatted as an RFC 5322
+// addr-spec. The domain is output as-is, so it ought to already be a dot-atom
+// or domain literal.
+func RenderAddrSpec(localPart, domain string) string {
+	return RenderLocalPart(localPart) + "@" + domain
+}
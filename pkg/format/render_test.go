@@ -0,0 +1,58 @@
+package format
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderPhrase(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, `""`, RenderPhrase(""))
+	assert.Equal(t, "Julia", RenderPhrase("Julia"))
+	assert.Equal(t, `"Winston Smith"`, RenderPhrase("Winston Smith"))
+	assert.Equal(t, `"J.R.R."`, RenderPhrase("J.R.R."))
+	assert.Equal(t, `"Sales, EMEA"`, RenderPhrase("Sales, EMEA"))
+	assert.Equal(t, `"a \"b\" \\ c"`, RenderPhrase(`a "b" \ c`))
+	assert.Equal(t, "=?utf-8?q?=C3=9Cnternehmen?=", RenderPhrase("Ünternehmen"))
+	assert.Equal(t, "=?utf-8?q?=3D=3Fx=3F=3D?=", RenderPhrase("=?x?="))
+	assert.Equal(t, "=?utf-8?q?a=2C_=C3=BC?=", RenderPhrase("a, ü"))
+}
+
+func TestRenderComment(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "(plain)", RenderComment("plain"))
+	assert.Equal(t, "(a (b) c)", RenderComment("a (b) c"))
+	assert.Equal(t, `(a \) b)`, RenderComment("a ) b"))
+	assert.Equal(t, `(back\\slash)`, RenderComment(`back\slash`))
+	assert.Equal(t, "(=?utf-8?q?=C2=A1Hola,_se=C3=B1or!?=)", RenderComment("¡Hola, señor!"))
+	assert.Equal(t, "(=?utf-8?q?=28=C3=BC=29?=)", RenderComment("(ü)"))
+}
+
+func TestRenderLocalPart(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "first.last", RenderLocalPart("first.last"))
+	assert.Equal(t, `".first"`, RenderLocalPart(".first"))
+	assert.Equal(t, `"two..dots"`, RenderLocalPart("two..dots"))
+	assert.Equal(t, `""`, RenderLocalPart(""))
+	assert.Equal(t, `"with space"`, RenderLocalPart("with space"))
+	assert.Equal(t, `"q\"b\\"`, RenderLocalPart(`q"b\`))
+}
+
+func TestEncodeWordsLength(t *testing.T) {
+	t.Parallel()
+
+	s := strings.Repeat("ü", 100)
+	ws := strings.Split(EncodeWords(s, false), " ")
+	assert.Greater(t, len(ws), 1)
+	for _, w := range ws {
+		assert.LessOrEqual(t, len(w), maxEncodedWordLength)
+		assert.True(t, strings.HasPrefix(w, "=?utf-8?q?"))
+		assert.True(t, strings.HasSuffix(w, "?="))
+		assert.Equal(t, 0, (len(w)-12)%6, "multi-byte characters are kept together")
+	}
+}
@@ -0,0 +1,49 @@
+package format
+
+import "strings"
+
+// IsUnsafeRune returns true if the rune should never appear in a display name
+// or comment. These are the characters that may be used to inject header lines
+// or to visually disguise text:
+//
+// * ASCII control characters other than horizontal tab (which includes NUL,
+// CR, and LF) and DEL,
+//
+// * Unicode C1 control characters, and
+//
+// * Unicode bidirectional embeddings, overrides, and isolates.
+func IsUnsafeRune(c rune) bool {
+	switch {
+	case c < ' ' && c != '\t':
+		return true
+	case c >= 0x7f && c <= 0x9f:
+		return true
+	case c >= 0x202a && c <= 0x202e:
+		return true
+	case c >= 0x2066 && c <= 0x2069:
+		return true
+	}
+
+	return false
+}
+
+// IndexUnsafe returns the byte offset of the first rune in the string for
+// which IsUnsafeRune returns true or -1 if there is none.
+func IndexUnsafe(s string) int {
+	return strings.IndexFunc(s, IsUnsafeRune)
+}
+
+// StripUnsafe returns the string with every rune for which IsUnsafeRune returns
+// true removed.
+func StripUnsafe(s string) string {
+	if IndexUnsafe(s) < 0 {
+		return s
+	}
+
+	return strings.Map(func(c rune) rune {
+		if IsUnsafeRune(c) {
+			return -1
+		}
+		return c
+	}, s)
+}
@@ -0,0 +1,29 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsUnsafeRune(t *testing.T) {
+	t.Parallel()
+
+	for _, c := range []rune{0, '\r', '\n', 0x1b, 0x7f, 0x85, 0x202e, 0x2067} {
+		assert.True(t, IsUnsafeRune(c), "%U", c)
+	}
+
+	for _, c := range []rune{'\t', ' ', 'a', '~', 'é', 0x200f} {
+		assert.False(t, IsUnsafeRune(c), "%U", c)
+	}
+}
+
+func TestStripUnsafe(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "plain", StripUnsafe("plain"))
+	assert.Equal(t, "Bcc: evil", StripUnsafe("\r\nBcc: evil\x00"))
+	assert.Equal(t, "gpj.exe", StripUnsafe("gpj\u202e.exe"))
+	assert.Equal(t, -1, IndexUnsafe("tab\tok"))
+	assert.Equal(t, 3, IndexUnsafe("abc\ndef"))
+}
@@ -126,8 +126,8 @@ func matchCurAngleAddr(cs []byte) (*rd.Match, []byte) {
 //  // group           =   display-name ":" [group-list] ";" [CFWS]
 func MatchGroup(cs []byte) (*rd.Match, []byte) {
 	var (
-		dn, c, gl, s *rd.Match
-		rcs          []byte
+		dn, c, gl, s, cfws *rd.Match
+		rcs                []byte
 	)
 
 	dn, cs = MatchDisplayName(cs)
@@ -149,7 +149,11 @@ func MatchGroup(cs []byte) (*rd.Match, []byte) {
 		return nil, nil
 	}
 
-	return rd.BuildMatch(TGroup, "display-name", dn, "", c, "group-list", gl, "", s), cs
+	if cfws, rcs = MatchCFWS(cs); cfws != nil {
+		cs = rcs
+	}
+
+	return rd.BuildMatch(TGroup, "display-name", dn, "", c, "group-list", gl, "", s, "post", cfws), cs
 }
 
 // MatchDisplayName matches a display name.
@@ -255,8 +259,8 @@ func MatchDomain(cs []byte) (*rd.Match, []byte) {
 //  // domain-literal  =   [CFWS] "[" *([FWS] dtext) [FWS] "]" [CFWS]
 func MatchDomainLiteral(cs []byte) (*rd.Match, []byte) {
 	var (
-		pl, lb, lit, rb *rd.Match
-		rcs             []byte
+		pl, lb, lit, fws, rb, pol *rd.Match
+		rcs                       []byte
 	)
 	if pl, rcs = MatchCFWS(cs); pl != nil {
 		cs = rcs
@@ -272,17 +276,27 @@ func MatchDomainLiteral(cs []byte) (*rd.Match, []byte) {
 		return nil, nil
 	}
 
+	if fws, rcs = MatchFWS(cs); fws != nil {
+		cs = rcs
+	}
+
 	rb, cs = rd.MatchOneRune(rd.TNone, cs, ']')
 	if rb == nil {
 		return nil, nil
 	}
 
+	if pol, rcs = MatchCFWS(cs); pol != nil {
+		cs = rcs
+	}
+
 	return rd.BuildMatch(
 		rd.TLiteral,
 		"pre-literal", pl,
 		"", lb,
 		"literal", lit,
+		"", fws,
 		"", rb,
+		"post-literal", pol,
 	), cs
 }
 
@@ -712,7 +726,7 @@ func MatchQuotedString(cs []byte) (*rd.Match, []byte) {
 		cs = rcs
 	}
 
-	return rd.BuildMatch(TQuotedString, "", cfws1, "", ldq, "quoted-string", qc, "", fws, "", rdq, "", cfws2), cs
+	return rd.BuildMatch(TQuotedString, "", cfws1, "", ldq, "quoted-string", qc, "", rdq, "", cfws2), cs
 }
 
 // MatchObsNoWSCtl matches a single character for various obsolete productions.
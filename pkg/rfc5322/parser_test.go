@@ -193,6 +193,35 @@ func TestMatchDomainLiteralHappy(t *testing.T) {
 	assert.Equal(t, []byte(mb), m.Content)
 }
 
+func TestMatchDomainLiteralHappyFWS(t *testing.T) {
+	t.Parallel()
+
+	mb := "[127.0.0.1 ]"
+
+	m, cs := MatchDomainLiteral([]byte(mb))
+	assert.NotNil(t, m)
+
+	assert.Empty(t, cs)
+	assert.Equal(t, rd.TLiteral, m.Tag)
+	assert.Equal(t, []byte(mb), m.Content)
+}
+
+func TestMatchDomainLiteralHappyCFWS(t *testing.T) {
+	t.Parallel()
+
+	mb := " (pre) [127.0.0.1] (post)"
+
+	m, cs := MatchDomainLiteral([]byte(mb))
+	assert.NotNil(t, m)
+
+	assert.Empty(t, cs)
+	assert.Equal(t, rd.TLiteral, m.Tag)
+	assert.Equal(t, []byte(mb), m.Content)
+	assert.Equal(t, []byte(" (pre) "), m.Group["pre-literal"].Content)
+	assert.Equal(t, []byte("127.0.0.1"), m.Group["literal"].Content)
+	assert.Equal(t, []byte(" (post)"), m.Group["post-literal"].Content)
+}
+
 func TestMatchDomainLiteralLiteralHappy(t *testing.T) {
 	t.Parallel()
 
@@ -663,3 +692,27 @@ func TestMatchObsDTextHappyQuotedPair(t *testing.T) {
 		assert.Equal(t, []byte(mb), m.Content)
 	}
 }
+
+func TestMatchQuotedStringTrailingFWS(t *testing.T) {
+	t.Parallel()
+
+	qs := "\"a \" "
+
+	m, cs := MatchQuotedString([]byte(qs))
+	assert.NotNil(t, m)
+
+	assert.Empty(t, cs)
+	assert.Equal(t, []byte(qs), m.Content)
+}
+
+func TestMatchGroupTrailingCFWS(t *testing.T) {
+	t.Parallel()
+
+	g := "Group: a@example.com; (trailing)"
+
+	m, cs := MatchGroup([]byte(g))
+	assert.NotNil(t, m)
+
+	assert.Empty(t, cs)
+	assert.Equal(t, []byte(g), m.Content)
+}